@@ -15,6 +15,15 @@ import (
 // @description	Realtime ranking API
 // @host			localhost:8080
 // @BasePath		/api/v1
+//
+// @securityDefinitions.apikey	ApiKeyAuth
+// @in							header
+// @name						X-API-Key
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				HS256 or RS256 JWT passed as "Bearer <token>"
 func main() {
 	logger := logutil.InitLogger()
 	defer logger.Sync()
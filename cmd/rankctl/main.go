@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"realtime_ranking/internal/handler"
+	"realtime_ranking/internal/ranker"
+	"realtime_ranking/pkg/envutil"
+	"realtime_ranking/pkg/logutil"
+	"realtime_ranking/pkg/redis"
+	"syscall"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: rankctl <command> [flags]
+
+commands:
+  verify    report inconsistencies between video hashes and ranking sets
+  export    write the leaderboards and video hashes as JSONL or CSV
+  import    restore a snapshot into an empty keyspace
+  migrate-seen
+            move the legacy user:<id>:interactions sets into the seen histories
+  migrate-followers
+            fill the creator follower sets from the user follow sets`
+
+// rankctl is the operator tool for the ranking keys
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger := logutil.InitLogger()
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "verify":
+		err = verify(ctx, os.Args[2:])
+	case "export":
+		err = export(ctx, os.Args[2:])
+	case "import":
+		err = restore(ctx, os.Args[2:])
+	case "migrate-seen":
+		err = migrateSeen(ctx, os.Args[2:])
+	case "migrate-followers":
+		err = migrateFollowers(ctx)
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Fatal(os.Args[1], zap.Error(err))
+	}
+}
+
+// audit records a change made with rankctl in the audit log like the admin API does, the
+// actor being the operator's login
+func audit(ctx context.Context, rdb *goredis.Client, action, targetType, targetID string, after any) error {
+	actor := handler.CLIActor + ":" + envutil.GetString("USER", "unknown")
+	if _, err := handler.NewAuditLog(rdb).Record(ctx, actor, action, targetType, targetID, "", nil, after); err != nil {
+		return fmt.Errorf("record %s in the audit log: %w", action, err)
+	}
+	return nil
+}
+
+func verify(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "rewrite inconsistent keys from rankings:global")
+	flags.Parse(args)
+
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	report, err := handler.VerifyRankings(ctx, redisClient, *repair)
+	if err != nil {
+		return err
+	}
+	if *repair {
+		if err := audit(ctx, redisClient, handler.ActionRepairRankings, handler.AuditTargetRanking, "",
+			map[string]int{"videos": report.Videos, "mismatches": len(report.Mismatches)}); err != nil {
+			return err
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func export(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", handler.SnapshotJSONL, "jsonl or csv")
+	output := flags.String("o", "", "output file (default stdout)")
+	flags.Parse(args)
+
+	snapshotFormat, err := handler.ParseSnapshotFormat(*format)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	return handler.ExportSnapshot(ctx, redisClient, out, snapshotFormat)
+}
+
+func restore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", handler.SnapshotJSONL, "jsonl or csv")
+	input := flags.String("i", "", "input file (default stdin)")
+	prefix := flags.String("prefix", "", "key prefix to restore under")
+	flags.Parse(args)
+
+	snapshotFormat, err := handler.ParseSnapshotFormat(*format)
+	if err != nil {
+		return err
+	}
+	in := os.Stdin
+	if *input != "" {
+		if in, err = os.Open(*input); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	result, err := handler.ImportSnapshot(ctx, redisClient, in, snapshotFormat, *prefix)
+	if err != nil {
+		return err
+	}
+	if err := audit(ctx, redisClient, handler.ActionImportSnapshot, handler.AuditTargetRanking, *prefix, result); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "imported %d records into %d keys\n", result.Records, result.Keys)
+	return nil
+}
+
+func migrateSeen(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate-seen", flag.ExitOnError)
+	at := flags.Int64("at", 0, "unix time the legacy videos are recorded as seen at (default now)")
+	flags.Parse(args)
+
+	seenAt := time.Now()
+	if *at != 0 {
+		seenAt = time.Unix(*at, 0)
+	}
+
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	// same bounds as the API so the migrated histories are trimmed like the live ones
+	seen := ranker.NewSeenHistory(redisClient,
+		int64(envutil.GetInt("SEEN_HISTORY_SIZE", 500)),
+		envutil.GetDuration("SEEN_HISTORY_TTL", 30*24*time.Hour),
+	)
+	result, err := seen.Migrate(ctx, seenAt)
+	if err != nil {
+		return err
+	}
+	if err := audit(ctx, redisClient, handler.ActionMigrateSeen, handler.AuditTargetUser, "", result); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "migrated %d videos of %d users\n", result.Videos, result.Users)
+	return nil
+}
+
+func migrateFollowers(ctx context.Context) error {
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	result, err := handler.BackfillFollowers(ctx, redisClient)
+	if err != nil {
+		return err
+	}
+	if err := audit(ctx, redisClient, handler.ActionBackfillFollowers, handler.AuditTargetCreator, "", result); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "backfilled %d follows of %d users\n", result.Follows, result.Users)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os/signal"
+	"realtime_ranking/internal/handler"
+	"realtime_ranking/pkg/logutil"
+	"realtime_ranking/pkg/redis"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// rebuild replays the interaction log with the current weights and regenerates
+// rankings:global, the creator rankings and the video scores.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "replay the log and report counts without writing")
+	flag.Parse()
+
+	logger := logutil.InitLogger()
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	redisClient := redis.NewRedisClient()
+	defer redisClient.Close()
+
+	events := handler.NewEventLog(redisClient, 0)
+	result, err := handler.RebuildRankings(ctx, redisClient, events, *dryRun)
+	if err != nil {
+		logger.Fatal("rebuild rankings", zap.Error(err))
+	}
+	logger.Info("rebuild finished",
+		zap.Bool("dry_run", *dryRun),
+		zap.Int("interactions", result.Interactions),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("excluded", result.Excluded),
+		zap.Int("videos", result.Videos),
+		zap.Int("creators", result.Creators),
+	)
+}
@@ -2,14 +2,19 @@ package api
 
 import (
 	"context"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"go.uber.org/zap"
 
+	"realtime_ranking/internal/handler"
+	"realtime_ranking/pkg/envutil"
 	"realtime_ranking/pkg/middleware"
 )
 
@@ -24,6 +29,8 @@ type ApiApplication struct {
 	srv    *http.Server
 	mux    *http.ServeMux
 	rdb    *redis.Client
+
+	snapshotter *handler.Snapshotter
 }
 
 func (api *ApiApplication) Start() {
@@ -34,6 +41,7 @@ func (api *ApiApplication) Start() {
 		}
 		api.logger.Sugar().Infof("stopped serving new connections.")
 	}()
+	go api.snapshotter.Run(api.ctx)
 }
 
 func (api *ApiApplication) Shutdown() {
@@ -55,21 +63,48 @@ func (api *ApiApplication) Shutdown() {
 	api.logger.Info("shut down")
 }
 
-func NewRouter(logger *zap.Logger, errorHandler middleware.OnError) (*http.ServeMux, *http.Server) {
+func NewRouter(logger *zap.Logger, errorHandler middleware.OnError, auth *middleware.Authenticator) (*http.ServeMux, *http.Server) {
 	mux := &http.ServeMux{}
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", 8080),
-		Handler: middleware.LoggerWrap(middleware.RecoverWrap(mux, errorHandler), logger),
+		Handler: middleware.LoggerWrap(middleware.RecoverWrap(middleware.AuthWrap(mux, auth, logger), errorHandler), logger),
 	}
 	return mux, srv
 }
 
+// newAuthenticator verifies HS256 JWTs with JWT_HS256_SECRET and RS256 JWTs with the PEM
+// public key at JWT_RS256_PUBLIC_KEY_FILE, an algorithm without a key is rejected
+func newAuthenticator(logger *zap.Logger, client *redis.Client) *middleware.Authenticator {
+	var rsaKey *rsa.PublicKey
+	if path := envutil.GetString("JWT_RS256_PUBLIC_KEY_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Fatal("failed to read JWT public key", zap.Error(err))
+		}
+		rsaKey, err = middleware.ParseRSAPublicKey(data)
+		if err != nil {
+			logger.Fatal("failed to parse JWT public key", zap.Error(err))
+		}
+	}
+	return middleware.NewAuthenticator(client,
+		[]byte(envutil.GetString("JWT_HS256_SECRET", "")),
+		rsaKey,
+		envutil.GetString("JWT_ISSUER", ""),
+	)
+}
+
 func NewApiApplication(ctx context.Context, logger *zap.Logger, client *redis.Client) *ApiApplication {
 	application := &ApiApplication{ctx: ctx, logger: logger, rdb: client}
-	mux, srv := NewRouter(logger, application.errorHandler)
+	mux, srv := NewRouter(logger, application.errorHandler, newAuthenticator(logger, client))
 	application.mux = mux
 	application.srv = srv
 
+	application.snapshotter = handler.NewSnapshotter(client, logger,
+		application.duration("HISTORY_INTERVAL", 5*time.Minute),
+		int64(envutil.GetInt("HISTORY_TOP_N", 100)),
+		envutil.GetDuration("HISTORY_RETENTION", 7*24*time.Hour),
+	)
+
 	application.setUpRoute()
 	return application
 }
@@ -1,11 +1,122 @@
 package api
 
 import (
+	"context"
+	"time"
+
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.uber.org/zap"
+	"realtime_ranking/internal/experiment"
+	"realtime_ranking/internal/fraud"
 	"realtime_ranking/internal/handler"
+	"realtime_ranking/internal/moderation"
+	"realtime_ranking/internal/ranker"
+	"realtime_ranking/pkg/envutil"
+	"realtime_ranking/pkg/middleware"
 )
 
 func (api *ApiApplication) setUpRoute() {
-	handler.NewRankingHandler(api.mux, api.rdb, api.logger)
+	// the hidden videos are derived from the moderation state, recompute them in case it was
+	// changed by an older version
+	if err := moderation.RebuildHidden(context.Background(), api.rdb); err != nil {
+		api.logger.Fatal("failed to rebuild hidden videos", zap.Error(err))
+	}
+	events := handler.NewEventLog(api.rdb, int64(envutil.GetInt("INTERACTION_LOG_MAXLEN", 0)))
+	risingWindow := api.duration("RISING_WINDOW", time.Hour)
+	risingBuckets := envutil.GetInt("RISING_BUCKETS", 6)
+	if risingBuckets < 1 || risingWindow/time.Duration(risingBuckets) < time.Second {
+		api.logger.Fatal("invalid rising buckets, must be at least 1 and at least 1s each",
+			zap.Int("buckets", risingBuckets), zap.Duration("window", risingWindow))
+	}
+	rising := handler.NewRisingBoard(api.rdb, risingWindow, risingBuckets)
+	rankers := ranker.DefaultRegistry(api.rdb, ranker.Config{
+		Strategy:        envutil.GetString("RANKING_STRATEGY", ranker.StrategyDefault),
+		SeenPolicy:      envutil.GetString("SEEN_POLICY", ranker.SeenBoost),
+		SeenWeight:      envutil.GetFloat("SEEN_WEIGHT", 50),
+		SeenWindow:      envutil.GetDuration("SEEN_WINDOW", 7*24*time.Hour),
+		CreatorMax:      envutil.GetInt("DIVERSITY_CREATOR_MAX", 0),
+		TopicMax:        envutil.GetInt("DIVERSITY_TOPIC_MAX", 0),
+		DiversityWindow: envutil.GetInt("DIVERSITY_WINDOW", 5),
+		InterleaveRatio: envutil.GetFloat("INTERLEAVE_RATIO", 0),
+	})
+	seen := ranker.NewSeenHistory(api.rdb,
+		int64(envutil.GetInt("SEEN_HISTORY_SIZE", 500)),
+		envutil.GetDuration("SEEN_HISTORY_TTL", 30*24*time.Hour),
+	)
+	related := ranker.NewCoInteractionIndex(api.rdb,
+		int64(envutil.GetInt("CO_INTERACTION_WINDOW", 50)),
+		int64(envutil.GetInt("CO_INTERACTION_SIZE", 200)),
+	)
+	limiter := middleware.NewRateLimiter(api.rdb, api.logger, envutil.GetInt("RATE_LIMIT_TRUSTED_PROXIES", 0))
+	typeLimits, err := middleware.ParseLimits(envutil.GetString("RATE_LIMIT_INTERACTION_TYPES", "report=10/1h"))
+	if err != nil {
+		api.logger.Fatal("invalid rate limit", zap.String("key", "RATE_LIMIT_INTERACTION_TYPES"), zap.Error(err))
+	}
+	interactionLimits := handler.RateLimits{
+		IP:     api.rateLimit("RATE_LIMIT_INTERACTION_IP", "600/1m"),
+		APIKey: api.rateLimit("RATE_LIMIT_INTERACTION_API_KEY", "0"),
+		User:   api.rateLimit("RATE_LIMIT_INTERACTION_USER", "120/1m"),
+		Types:  typeLimits,
+	}
+	followLimits := handler.RateLimits{
+		IP:   api.rateLimit("RATE_LIMIT_FOLLOW_IP", "120/1m"),
+		User: api.rateLimit("RATE_LIMIT_FOLLOW_USER", "30/1m"),
+	}
+	detector := fraud.NewDetector(api.rdb, api.duration("FRAUD_WINDOW", 10*time.Minute), fraud.DefaultRules(fraud.Config{
+		UserVelocityMax:   int64(envutil.GetInt("FRAUD_USER_VELOCITY_MAX", 300)),
+		PairVelocityMax:   int64(envutil.GetInt("FRAUD_PAIR_VELOCITY_MAX", 5)),
+		UniqueRatioMin:    envutil.GetFloat("FRAUD_UNIQUE_RATIO_MIN", 0.2),
+		UniqueRatioSample: int64(envutil.GetInt("FRAUD_UNIQUE_RATIO_SAMPLE", 200)),
+		NewAccountAge:     envutil.GetDuration("FRAUD_NEW_ACCOUNT_AGE", 24*time.Hour),
+		NewAccountWeight:  envutil.GetFloat("FRAUD_NEW_ACCOUNT_WEIGHT", 0.5),
+	}))
+	audit := handler.NewAuditLog(api.rdb)
+	trustPenalty := envutil.GetFloat("TRUST_PENALTY", 0.05)
+	personal := handler.NewPersonalCache(api.rdb, envutil.GetDuration("PERSONAL_CACHE_TTL", 5*time.Minute))
+	experiments, err := experiment.Load(envutil.GetString("EXPERIMENTS_FILE", ""), rankers)
+	if err != nil {
+		api.logger.Fatal("failed to load experiments", zap.Error(err))
+	}
+	rankings := handler.NewRankingHandler(api.mux, api.rdb, api.logger, handler.RankingOptions{
+		Events:      events,
+		Rising:      rising,
+		Rankers:     rankers,
+		Experiments: experiments,
+		Seen:        seen,
+		Related:     related,
+		Personal:    personal,
+		Fraud:       detector,
+
+		ReportThreshold:   int64(envutil.GetInt("REPORT_THRESHOLD", 5)),
+		WatchTolerance:    envutil.GetDuration("WATCH_TIME_TOLERANCE", 10*time.Second),
+		RewatchWindow:     envutil.GetDuration("REWATCH_WINDOW", 24*time.Hour),
+		Audit:             audit,
+		TrustPenalty:      trustPenalty,
+		Limiter:           limiter,
+		InteractionLimits: interactionLimits,
+	})
+	handler.NewAdminHandler(api.mux, api.rdb, api.logger, audit, events, trustPenalty, rankings)
+	handler.NewVideoHandler(api.mux, api.rdb, api.logger, related)
+	handler.NewCreatorHandler(api.mux, api.rdb, api.logger)
+	handler.NewUserHandler(api.mux, api.rdb, api.logger, personal, limiter, followLimits)
 	api.mux.Handle("/swagger/", httpSwagger.WrapHandler)
 }
+
+// rateLimit reads a <rate>/<period> limit from the environment, an empty value or 0 disables it
+func (api *ApiApplication) rateLimit(key, defaultV string) middleware.Limit {
+	limit, err := middleware.ParseLimit(envutil.GetString(key, defaultV))
+	if err != nil {
+		api.logger.Fatal("invalid rate limit", zap.String("key", key), zap.Error(err))
+	}
+	return limit
+}
+
+// duration reads a duration from the environment. Values under a second would divide
+// windows into zero-length buckets, so they are rejected at startup.
+func (api *ApiApplication) duration(key string, defaultV time.Duration) time.Duration {
+	d := envutil.GetDuration(key, defaultV)
+	if d < time.Second {
+		api.logger.Fatal("invalid duration, must be at least 1s", zap.String("key", key), zap.Duration("value", d))
+	}
+	return d
+}
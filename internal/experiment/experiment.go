@@ -0,0 +1,161 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"realtime_ranking/internal/ranker"
+	"slices"
+)
+
+// Surfaces an experiment can run on
+const (
+	SurfacePersonal = "personal" // GET /api/v1/ranking/personal, variants pick a ranker strategy
+	SurfaceGlobal   = "global"   // GET /api/v1/ranking, variants pick a board
+)
+
+// buckets is the number of traffic buckets, variant weights are percentages of it
+const buckets = 100
+
+// boards are the boards the variants of the global surface can serve
+var boards = map[string]bool{"": true, "global": true, "rising": true}
+
+// Variant is one arm of an experiment
+type Variant struct {
+	ID string `json:"id"`
+	// Weight is the percentage of users assigned to the variant
+	Weight int `json:"weight"`
+	// Strategy is the ranker strategy (personal) or board (global) served to the variant
+	Strategy string `json:"strategy,omitempty"`
+	// Params override the weights of the strategy, e.g. follow_boost
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// Experiment splits the users of a surface between variants. Users whose bucket falls
+// beyond the sum of the variant weights are not enrolled.
+type Experiment struct {
+	ID       string    `json:"id"`
+	Salt     string    `json:"salt"`
+	Surface  string    `json:"surface"`
+	Variants []Variant `json:"variants"`
+}
+
+// Bucket deterministically maps a user to [0, 100) for this experiment
+func (e Experiment) Bucket(userID string) int {
+	h := fnv.New64a()
+	h.Write([]byte(e.Salt))
+	h.Write([]byte{':'})
+	h.Write([]byte(userID))
+	return int(h.Sum64() % buckets)
+}
+
+// Assign returns the variant of a user, false when the user is not enrolled
+func (e Experiment) Assign(userID string) (Variant, bool) {
+	bucket := e.Bucket(userID)
+	upper := 0
+	for _, variant := range e.Variants {
+		upper += variant.Weight
+		if bucket < upper {
+			return variant, true
+		}
+	}
+	return Variant{}, false
+}
+
+func (e Experiment) validate(rankers *ranker.Registry) error {
+	if e.ID == "" {
+		return fmt.Errorf("experiment id is required")
+	}
+	if e.Surface != SurfacePersonal && e.Surface != SurfaceGlobal {
+		return fmt.Errorf("experiment %s: unknown surface %q", e.ID, e.Surface)
+	}
+	total := 0
+	for _, variant := range e.Variants {
+		if variant.ID == "" || variant.Weight < 0 {
+			return fmt.Errorf("experiment %s: variants need an id and a non-negative weight", e.ID)
+		}
+		if err := variant.validate(e.Surface, rankers); err != nil {
+			return fmt.Errorf("experiment %s: variant %s: %w", e.ID, variant.ID, err)
+		}
+		total += variant.Weight
+	}
+	if total == 0 || total > buckets {
+		return fmt.Errorf("experiment %s: variant weights must sum to between 1 and %d", e.ID, buckets)
+	}
+	return nil
+}
+
+// validate checks the strategy and params of a variant against the boards of the global
+// surface, or the strategies registered in rankers for the personal surface
+func (v Variant) validate(surface string, rankers *ranker.Registry) error {
+	if surface == SurfaceGlobal {
+		if !boards[v.Strategy] {
+			return fmt.Errorf("unknown board %q", v.Strategy)
+		}
+		if len(v.Params) > 0 {
+			return fmt.Errorf("boards take no params")
+		}
+		return nil
+	}
+	params, ok := rankers.Params(v.Strategy)
+	if !ok {
+		return fmt.Errorf("unknown strategy %q", v.Strategy)
+	}
+	for name := range v.Params {
+		if !slices.Contains(params, name) {
+			return fmt.Errorf("strategy %s does not read param %q", rankers.Resolve(v.Strategy), name)
+		}
+	}
+	return nil
+}
+
+// Set holds the running experiments, at most one per surface
+type Set struct {
+	experiments map[string]Experiment
+}
+
+// NewSet validates the experiments, the strategies and params of personal variants must
+// be known to rankers
+func NewSet(rankers *ranker.Registry, experiments ...Experiment) (*Set, error) {
+	set := &Set{experiments: make(map[string]Experiment)}
+	for _, experiment := range experiments {
+		if err := experiment.validate(rankers); err != nil {
+			return nil, err
+		}
+		if _, ok := set.experiments[experiment.Surface]; ok {
+			return nil, fmt.Errorf("experiment %s: surface %s already has an experiment", experiment.ID, experiment.Surface)
+		}
+		set.experiments[experiment.Surface] = experiment
+	}
+	return set, nil
+}
+
+// Load reads a JSON array of experiments. An empty path yields an empty set.
+func Load(path string, rankers *ranker.Registry) (*Set, error) {
+	if path == "" {
+		return NewSet(rankers)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read experiments: %w", err)
+	}
+	var experiments []Experiment
+	if err := json.Unmarshal(raw, &experiments); err != nil {
+		return nil, fmt.Errorf("parse experiments: %w", err)
+	}
+	return NewSet(rankers, experiments...)
+}
+
+// Assign returns the experiment and variant of a user on a surface
+func (s *Set) Assign(surface, userID string) (Experiment, Variant, bool) {
+	if s == nil || userID == "" {
+		return Experiment{}, Variant{}, false
+	}
+	experiment, ok := s.experiments[surface]
+	if !ok {
+		return Experiment{}, Variant{}, false
+	}
+	variant, ok := experiment.Assign(userID)
+	return experiment, variant, ok
+}
@@ -0,0 +1,112 @@
+package experiment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"realtime_ranking/internal/ranker"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssign(t *testing.T) {
+	exp := Experiment{
+		ID:      "boosts",
+		Salt:    "s1",
+		Surface: SurfacePersonal,
+		Variants: []Variant{
+			{ID: "control", Weight: 40, Strategy: "default"},
+			{ID: "treatment", Weight: 40, Strategy: "default", Params: map[string]float64{"follow_boost": 200}},
+		},
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		first, ok := exp.Assign("user1")
+		second, _ := exp.Assign("user1")
+		assert.Equal(t, first, second)
+		assert.True(t, ok || exp.Bucket("user1") >= 80)
+	})
+
+	t.Run("traffic split", func(t *testing.T) {
+		counts := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			variant, ok := exp.Assign(fmt.Sprintf("user%d", i))
+			if !ok {
+				variant.ID = "none"
+			}
+			counts[variant.ID]++
+		}
+		assert.InDelta(t, 4000, counts["control"], 300)
+		assert.InDelta(t, 4000, counts["treatment"], 300)
+		assert.InDelta(t, 2000, counts["none"], 300)
+	})
+
+	t.Run("salt reshuffles users", func(t *testing.T) {
+		other := exp
+		other.Salt = "s2"
+		moved := 0
+		for i := 0; i < 1000; i++ {
+			userID := fmt.Sprintf("user%d", i)
+			if exp.Bucket(userID) != other.Bucket(userID) {
+				moved++
+			}
+		}
+		assert.Greater(t, moved, 900)
+	})
+}
+
+func TestLoad(t *testing.T) {
+	rankers := ranker.DefaultRegistry(nil, ranker.Config{Strategy: ranker.StrategyDefault})
+
+	t.Run("empty path", func(t *testing.T) {
+		set, err := Load("", rankers)
+		require.NoError(t, err)
+		_, _, ok := set.Assign(SurfacePersonal, "user1")
+		assert.False(t, ok)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "experiments.json")
+		require.NoError(t, os.WriteFile(path, []byte(`[
+			{"id": "board", "salt": "x", "surface": "global", "variants": [{"id": "rising", "weight": 100, "strategy": "rising"}]}
+		]`), 0o600))
+
+		set, err := Load(path, rankers)
+		require.NoError(t, err)
+		exp, variant, ok := set.Assign(SurfaceGlobal, "user1")
+		require.True(t, ok)
+		assert.Equal(t, "board", exp.ID)
+		assert.Equal(t, "rising", variant.Strategy)
+	})
+
+	t.Run("invalid weights", func(t *testing.T) {
+		_, err := NewSet(rankers, Experiment{ID: "x", Surface: SurfaceGlobal, Variants: []Variant{{ID: "a", Weight: 101}}})
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicate surface", func(t *testing.T) {
+		exp := Experiment{ID: "x", Surface: SurfaceGlobal, Variants: []Variant{{ID: "a", Weight: 50}}}
+		_, err := NewSet(rankers, exp, exp)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown strategies and params", func(t *testing.T) {
+		for _, variant := range []Variant{
+			{ID: "a", Weight: 50, Strategy: "trending"},
+			{ID: "a", Weight: 50, Strategy: ranker.StrategyGlobal, Params: map[string]float64{"follow_boost": 0}},
+			{ID: "a", Weight: 50, Params: map[string]float64{"folow_boost": 0}},
+		} {
+			_, err := NewSet(rankers, Experiment{ID: "x", Surface: SurfacePersonal, Variants: []Variant{variant}})
+			assert.Error(t, err, variant)
+		}
+		_, err := NewSet(rankers, Experiment{ID: "x", Surface: SurfaceGlobal, Variants: []Variant{{ID: "a", Weight: 50, Strategy: "trending"}}})
+		assert.Error(t, err)
+
+		_, err = NewSet(rankers, Experiment{ID: "x", Surface: SurfacePersonal, Variants: []Variant{
+			{ID: "a", Weight: 50, Strategy: ranker.StrategyFresh, Params: map[string]float64{"freshness_boost": 0, "creator_max": 2}},
+		}})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,249 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Action is what the rule engine does with an interaction
+type Action string
+
+const (
+	// ActionAllow scores the interaction with its full weight
+	ActionAllow Action = "allow"
+	// ActionDiscount scores the interaction with a reduced weight
+	ActionDiscount Action = "discount"
+	// ActionDelay holds the interaction for review, it is scored once released
+	ActionDelay Action = "delay"
+	// ActionDrop never scores the interaction
+	ActionDrop Action = "drop"
+)
+
+var severity = map[Action]int{
+	ActionAllow:    0,
+	ActionDiscount: 1,
+	ActionDelay:    2,
+	ActionDrop:     3,
+}
+
+// Event is an interaction evaluated by the detector
+type Event struct {
+	UserID  string
+	VideoID string
+	Type    string
+}
+
+// Signals are the fraud signals of an interaction, counted over the detection window
+// including the interaction itself
+type Signals struct {
+	// UserVelocity is the number of interactions of the user
+	UserVelocity int64 `json:"user_velocity"`
+	// PairVelocity is the number of interactions of the user with the video
+	PairVelocity int64 `json:"pair_velocity"`
+	// VideoInteractions is the number of interactions with the video and UniqueUsers
+	// the approximate number of distinct users behind them
+	VideoInteractions int64 `json:"video_interactions"`
+	UniqueUsers       int64 `json:"unique_users"`
+	// AccountAge is the age of the user account in seconds, from the created_at of the
+	// user hash, -1 when the user hash has none
+	AccountAge int64 `json:"account_age"`
+	// Bot is set for users confirmed as bots
+	Bot bool `json:"bot"`
+}
+
+// UniqueRatio is the share of distinct users among the interactions with the video
+func (s Signals) UniqueRatio() float64 {
+	if s.VideoInteractions == 0 {
+		return 1
+	}
+	return float64(s.UniqueUsers) / float64(s.VideoInteractions)
+}
+
+// Rule applies an action to the interactions whose signals match
+type Rule struct {
+	Name string
+	// Types restricts the rule to some interaction types, empty applies it to all
+	Types  []string
+	Match  func(Signals) bool
+	Action Action
+	// Weight scales the score of discounted interactions, a weight of zero or less drops them
+	Weight float64
+}
+
+// Decision is the outcome of the rule engine for an interaction
+type Decision struct {
+	Action Action `json:"action"`
+	// Weight scales the score of the interaction, the product of the discount weights
+	Weight  float64  `json:"weight"`
+	Rules   []string `json:"rules,omitempty"`
+	Signals Signals  `json:"signals"`
+	// Trust is the trust factor of the user when the interaction was screened
+	Trust float64 `json:"trust"`
+}
+
+// ScoreWeight is the weight the interaction is scored with, the decision weight times the
+// trust of the user. It is positive for every decision that is not a drop.
+func (d Decision) ScoreWeight() float64 {
+	return d.Weight * d.Trust
+}
+
+// Decide applies the matching rules to an interaction: the most severe action wins and
+// discount weights multiply
+func Decide(rules []Rule, event Event, signals Signals) Decision {
+	decision := Decision{Action: ActionAllow, Weight: 1, Signals: signals, Trust: DefaultTrust}
+	for _, rule := range rules {
+		if len(rule.Types) > 0 && !slices.Contains(rule.Types, event.Type) {
+			continue
+		}
+		if !rule.Match(signals) {
+			continue
+		}
+		decision.Rules = append(decision.Rules, rule.Name)
+		action := rule.Action
+		if action == ActionDiscount {
+			if rule.Weight <= 0 {
+				// scored interactions always carry a positive weight
+				action = ActionDrop
+			} else {
+				decision.Weight *= rule.Weight
+			}
+		}
+		if severity[action] > severity[decision.Action] {
+			decision.Action = action
+		}
+	}
+	return decision
+}
+
+// Config configures the default rules, zero thresholds disable their rule
+type Config struct {
+	// UserVelocityMax drops the interactions of users above that many per window
+	UserVelocityMax int64
+	// PairVelocityMax drops the likes, comments and shares of a user with a video above
+	// that many per window
+	PairVelocityMax int64
+	// UniqueRatioMin delays the interactions with videos whose share of distinct users
+	// falls below it, once they had UniqueRatioSample interactions in the window
+	UniqueRatioMin    float64
+	UniqueRatioSample int64
+	// NewAccountAge discounts the interactions of accounts younger than it to NewAccountWeight
+	NewAccountAge    time.Duration
+	NewAccountWeight float64
+}
+
+// DefaultRules builds the rules of the config. Confirmed bots are always dropped.
+func DefaultRules(config Config) []Rule {
+	rules := []Rule{{
+		Name:   "bot",
+		Match:  func(s Signals) bool { return s.Bot },
+		Action: ActionDrop,
+	}}
+	if config.UserVelocityMax > 0 {
+		rules = append(rules, Rule{
+			Name:   "user_velocity",
+			Match:  func(s Signals) bool { return s.UserVelocity > config.UserVelocityMax },
+			Action: ActionDrop,
+		})
+	}
+	if config.PairVelocityMax > 0 {
+		rules = append(rules, Rule{
+			Name:   "pair_velocity",
+			Types:  []string{"like", "comment", "share"},
+			Match:  func(s Signals) bool { return s.PairVelocity > config.PairVelocityMax },
+			Action: ActionDrop,
+		})
+	}
+	if config.UniqueRatioMin > 0 {
+		rules = append(rules, Rule{
+			Name: "unique_ratio",
+			Match: func(s Signals) bool {
+				return s.VideoInteractions >= config.UniqueRatioSample && s.UniqueRatio() < config.UniqueRatioMin
+			},
+			Action: ActionDelay,
+		})
+	}
+	if config.NewAccountAge > 0 && config.NewAccountWeight < 1 {
+		rule := Rule{
+			Name:   "new_account",
+			Match:  func(s Signals) bool { return s.AccountAge >= 0 && s.AccountAge < int64(config.NewAccountAge.Seconds()) },
+			Action: ActionDiscount,
+			Weight: config.NewAccountWeight,
+		}
+		if rule.Weight <= 0 {
+			rule.Action, rule.Weight = ActionDrop, 0
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Detector counts the fraud signals of interactions over fixed windows and applies its rules
+type Detector struct {
+	redis  *redis.Client
+	window time.Duration
+	rules  []Rule
+	now    func() time.Time
+}
+
+func NewDetector(redis *redis.Client, window time.Duration, rules []Rule) *Detector {
+	return &Detector{
+		redis:  redis,
+		window: window,
+		rules:  rules,
+		now:    time.Now,
+	}
+}
+
+// Evaluate counts an interaction and decides what to do with it
+func (d *Detector) Evaluate(ctx context.Context, event Event) (Decision, error) {
+	signals, err := d.signals(ctx, event)
+	if err != nil {
+		return Decision{}, err
+	}
+	return Decide(d.rules, event, signals), nil
+}
+
+func (d *Detector) signals(ctx context.Context, event Event) (Signals, error) {
+	now := d.now()
+	bucket := now.Unix() / int64(d.window.Seconds())
+	userKey := fmt.Sprintf("fraud:user:%s:%d", event.UserID, bucket)
+	pairKey := fmt.Sprintf("fraud:pair:%s:%s:%d", event.UserID, event.VideoID, bucket)
+	videoKey := fmt.Sprintf("fraud:video:%s:%d", event.VideoID, bucket)
+	usersKey := fmt.Sprintf("fraud:video:%s:users:%d", event.VideoID, bucket)
+
+	pipe := d.redis.Pipeline()
+	userCmd := pipe.Incr(ctx, userKey)
+	pairCmd := pipe.Incr(ctx, pairKey)
+	videoCmd := pipe.Incr(ctx, videoKey)
+	pipe.PFAdd(ctx, usersKey, event.UserID)
+	uniqueCmd := pipe.PFCount(ctx, usersKey)
+	for _, key := range []string{userKey, pairKey, videoKey, usersKey} {
+		pipe.Expire(ctx, key, 2*d.window)
+	}
+	createdAtCmd := pipe.HGet(ctx, fmt.Sprintf("user:%s", event.UserID), "created_at")
+	botCmd := pipe.SIsMember(ctx, BotsKey, event.UserID)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return Signals{}, err
+	}
+
+	// without a creation time the account age is unknown, the first interaction seen here
+	// says nothing of the accounts that existed before the detector
+	accountAge := int64(-1)
+	if createdAt, err := strconv.ParseInt(createdAtCmd.Val(), 10, 64); err == nil {
+		accountAge = max(now.Unix()-createdAt, 0)
+	}
+	return Signals{
+		UserVelocity:      userCmd.Val(),
+		PairVelocity:      pairCmd.Val(),
+		VideoInteractions: videoCmd.Val(),
+		UniqueUsers:       uniqueCmd.Val(),
+		AccountAge:        accountAge,
+		Bot:               botCmd.Val(),
+	}, nil
+}
@@ -0,0 +1,154 @@
+package fraud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTest(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+	return client, mr
+}
+
+func TestDecide(t *testing.T) {
+	rules := DefaultRules(Config{
+		UserVelocityMax:   10,
+		PairVelocityMax:   2,
+		UniqueRatioMin:    0.5,
+		UniqueRatioSample: 4,
+		NewAccountAge:     time.Hour,
+		NewAccountWeight:  0.5,
+	})
+	established := Signals{UserVelocity: 1, PairVelocity: 1, VideoInteractions: 1, UniqueUsers: 1, AccountAge: 7200}
+
+	tests := []struct {
+		name    string
+		event   Event
+		signals func(Signals) Signals
+		action  Action
+		weight  float64
+		rules   []string
+	}{
+		{"allow", Event{Type: "like"}, func(s Signals) Signals { return s }, ActionAllow, 1, nil},
+		{"new account", Event{Type: "like"}, func(s Signals) Signals { s.AccountAge = 60; return s }, ActionDiscount, 0.5, []string{"new_account"}},
+		{"unknown account age", Event{Type: "like"}, func(s Signals) Signals { s.AccountAge = -1; return s }, ActionAllow, 1, nil},
+		{"pair velocity", Event{Type: "share"}, func(s Signals) Signals { s.PairVelocity = 3; return s }, ActionDrop, 1, []string{"pair_velocity"}},
+		{"pair velocity of views", Event{Type: "view"}, func(s Signals) Signals { s.PairVelocity = 3; return s }, ActionAllow, 1, nil},
+		{"low unique ratio", Event{Type: "view"}, func(s Signals) Signals { s.VideoInteractions, s.UniqueUsers = 4, 1; return s }, ActionDelay, 1, []string{"unique_ratio"}},
+		{"small sample", Event{Type: "view"}, func(s Signals) Signals { s.VideoInteractions, s.UniqueUsers = 3, 1; return s }, ActionAllow, 1, nil},
+		{"most severe wins", Event{Type: "like"}, func(s Signals) Signals {
+			s.UserVelocity, s.AccountAge = 11, 0
+			return s
+		}, ActionDrop, 0.5, []string{"user_velocity", "new_account"}},
+		{"bot", Event{Type: "view"}, func(s Signals) Signals { s.Bot = true; return s }, ActionDrop, 1, []string{"bot"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decision := Decide(rules, test.event, test.signals(established))
+			assert.Equal(t, test.action, decision.Action)
+			assert.Equal(t, test.weight, decision.Weight)
+			assert.Equal(t, test.rules, decision.Rules)
+		})
+	}
+
+	t.Run("zero weight drops", func(t *testing.T) {
+		rules := DefaultRules(Config{NewAccountAge: time.Hour, NewAccountWeight: 0})
+		decision := Decide(rules, Event{Type: "like"}, Signals{AccountAge: 60})
+		assert.Equal(t, ActionDrop, decision.Action)
+
+		rules = []Rule{{Name: "discount", Match: func(Signals) bool { return true }, Action: ActionDiscount, Weight: -1}}
+		decision = Decide(rules, Event{Type: "like"}, established)
+		assert.Equal(t, ActionDrop, decision.Action)
+		assert.Equal(t, 1.0, decision.Weight)
+	})
+}
+
+func TestDetector(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	now := time.Unix(1690000200, 0)
+	detector := NewDetector(client, 10*time.Minute, nil)
+	detector.now = func() time.Time { return now }
+	mr.HSet("user:user2", "created_at", "1680000000")
+
+	for range 3 {
+		_, err := detector.Evaluate(ctx, Event{UserID: "user1", VideoID: "video1", Type: "like"})
+		require.NoError(t, err)
+	}
+	now = now.Add(time.Minute)
+	decision, err := detector.Evaluate(ctx, Event{UserID: "user1", VideoID: "video2", Type: "like"})
+	require.NoError(t, err)
+	assert.Equal(t, Signals{UserVelocity: 4, PairVelocity: 1, VideoInteractions: 1, UniqueUsers: 1, AccountAge: -1}, decision.Signals)
+
+	decision, err = detector.Evaluate(ctx, Event{UserID: "user2", VideoID: "video1", Type: "like"})
+	require.NoError(t, err)
+	assert.Equal(t, Signals{UserVelocity: 1, PairVelocity: 1, VideoInteractions: 4, UniqueUsers: 2, AccountAge: 10000260}, decision.Signals)
+	assert.Equal(t, 0.5, decision.Signals.UniqueRatio())
+
+	t.Run("new window", func(t *testing.T) {
+		now = now.Add(10 * time.Minute)
+		_, err := ConfirmBot(ctx, client, "user1")
+		require.NoError(t, err)
+		decision, err := detector.Evaluate(ctx, Event{UserID: "user1", VideoID: "video1", Type: "like"})
+		require.NoError(t, err)
+		assert.Equal(t, Signals{UserVelocity: 1, PairVelocity: 1, VideoInteractions: 1, UniqueUsers: 1, AccountAge: -1, Bot: true}, decision.Signals)
+	})
+}
+
+func TestFlags(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	interaction := map[string]string{"video_id": "video1", "type": "share", "user_id": "user1"}
+	dropped, err := Store(ctx, client, Event{UserID: "user1", VideoID: "video1", Type: "share"}, interaction,
+		Decision{Action: ActionDrop, Weight: 1, Rules: []string{"pair_velocity"}})
+	require.NoError(t, err)
+	delayed, err := Store(ctx, client, Event{UserID: "user2", VideoID: "video1", Type: "view"}, interaction,
+		Decision{Action: ActionDelay, Weight: 1, Rules: []string{"unique_ratio"}})
+	require.NoError(t, err)
+
+	flags, err := Flags(ctx, client, 10, false)
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, delayed, flags[0].ID)
+	assert.True(t, flags[0].Pending)
+	assert.Equal(t, []string{"unique_ratio"}, flags[0].Decision.Rules)
+	assert.Equal(t, dropped, flags[1].ID)
+	assert.False(t, flags[1].Pending)
+	assert.JSONEq(t, `{"video_id":"video1","type":"share","user_id":"user1"}`, string(flags[1].Interaction))
+
+	flags, err = Flags(ctx, client, 10, true)
+	require.NoError(t, err)
+	require.Len(t, flags, 1)
+	assert.Equal(t, delayed, flags[0].ID)
+
+	resolved, err := Resolve(ctx, client, delayed)
+	require.NoError(t, err)
+	assert.True(t, resolved)
+	resolved, err = Resolve(ctx, client, delayed)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+
+	flag, ok, err := GetFlag(ctx, client, delayed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, flag.Pending)
+
+	_, ok, err = GetFlag(ctx, client, "not-an-id")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
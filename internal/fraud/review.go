@@ -0,0 +1,218 @@
+package fraud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Review state keys
+const (
+	FlagsKey   = "fraud:flags"   // stream of the delayed and dropped interactions
+	PendingKey = "fraud:pending" // flag IDs of the delayed interactions awaiting review
+	BotsKey    = "fraud:bots"    // users confirmed as bots
+)
+
+// Flag is a delayed or dropped interaction stored for review
+type Flag struct {
+	ID      string `json:"id"`
+	UserID  string `json:"user_id"`
+	VideoID string `json:"video_id"`
+	Type    string `json:"type"`
+	// Interaction is the interaction as submitted, scored with the decision weight on release
+	Interaction json.RawMessage `json:"interaction" swaggertype:"object"`
+	Decision    Decision        `json:"decision"`
+	// Pending is set while a delayed interaction awaits release or rejection
+	Pending bool `json:"pending"`
+}
+
+// Store records a flagged interaction, delayed ones are pending review
+func Store(ctx context.Context, rdb *redis.Client, event Event, interaction any, decision Decision) (string, error) {
+	payload, err := json.Marshal(interaction)
+	if err != nil {
+		return "", err
+	}
+	rawDecision, err := json.Marshal(decision)
+	if err != nil {
+		return "", err
+	}
+	id, err := rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: FlagsKey,
+		Values: []string{
+			"user_id", event.UserID,
+			"video_id", event.VideoID,
+			"type", event.Type,
+			"interaction", string(payload),
+			"decision", string(rawDecision),
+		},
+	}).Result()
+	if err != nil {
+		return "", err
+	}
+	if decision.Action == ActionDelay {
+		if err := rdb.SAdd(ctx, PendingKey, id).Err(); err != nil {
+			return id, err
+		}
+	}
+	return id, nil
+}
+
+// Flags returns the latest flagged interactions, newest first, only the pending ones
+// when pendingOnly is set
+func Flags(ctx context.Context, rdb *redis.Client, limit int64, pendingOnly bool) ([]Flag, error) {
+	var messages []redis.XMessage
+	var err error
+	if pendingOnly {
+		var ids []string
+		if ids, err = rdb.SMembers(ctx, PendingKey).Result(); err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			found, err := rdb.XRange(ctx, FlagsKey, id, id).Result()
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, found...)
+		}
+		sortMessages(messages)
+		if int64(len(messages)) > limit {
+			messages = messages[:limit]
+		}
+	} else if messages, err = rdb.XRevRangeN(ctx, FlagsKey, "+", "-", limit).Result(); err != nil {
+		return nil, err
+	}
+
+	pending, err := pendingSet(ctx, rdb, messages)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]Flag, len(messages))
+	for i, message := range messages {
+		flags[i] = flagFromMessage(message, pending[i])
+	}
+	return flags, nil
+}
+
+// GetFlag returns a flagged interaction
+func GetFlag(ctx context.Context, rdb *redis.Client, id string) (Flag, bool, error) {
+	if ms, seq, ok := strings.Cut(id, "-"); !ok || !isDigits(ms) || !isDigits(seq) {
+		return Flag{}, false, nil
+	}
+	messages, err := rdb.XRange(ctx, FlagsKey, id, id).Result()
+	if err != nil || len(messages) == 0 {
+		return Flag{}, false, err
+	}
+	pending, err := rdb.SIsMember(ctx, PendingKey, id).Result()
+	if err != nil {
+		return Flag{}, false, err
+	}
+	return flagFromMessage(messages[0], pending), true, nil
+}
+
+func claimKey(id string) string {
+	return fmt.Sprintf("fraud:flags:claim:%s", id)
+}
+
+// Claim reserves a delayed interaction for one review for at most ttl. It returns false when
+// another review holds it, so that concurrent reviews apply it once.
+func Claim(ctx context.Context, rdb *redis.Client, id string, ttl time.Duration) (bool, error) {
+	return rdb.SetNX(ctx, claimKey(id), 1, ttl).Result()
+}
+
+// Unclaim releases a delayed interaction after a failed review
+func Unclaim(ctx context.Context, rdb *redis.Client, id string) error {
+	return rdb.Del(ctx, claimKey(id)).Err()
+}
+
+// Resolve ends the review of a delayed interaction. It returns false when the
+// interaction was not pending, so that concurrent reviews resolve it once.
+func Resolve(ctx context.Context, rdb *redis.Client, id string) (bool, error) {
+	removed, err := rdb.SRem(ctx, PendingKey, id).Result()
+	return removed == 1, err
+}
+
+// ConfirmBot marks a user as a bot, their later interactions are dropped. It returns
+// false when the user already was.
+func ConfirmBot(ctx context.Context, rdb *redis.Client, userID string) (bool, error) {
+	added, err := rdb.SAdd(ctx, BotsKey, userID).Result()
+	return added == 1, err
+}
+
+// IsBot reports whether a user is confirmed as a bot
+func IsBot(ctx context.Context, rdb *redis.Client, userID string) (bool, error) {
+	return rdb.SIsMember(ctx, BotsKey, userID).Result()
+}
+
+// Bots returns the users confirmed as bots
+func Bots(ctx context.Context, rdb *redis.Client) (map[string]struct{}, error) {
+	members, err := rdb.SMembers(ctx, BotsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	bots := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		bots[member] = struct{}{}
+	}
+	return bots, nil
+}
+
+func pendingSet(ctx context.Context, rdb *redis.Client, messages []redis.XMessage) ([]bool, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	ids := make([]any, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	return rdb.SMIsMember(ctx, PendingKey, ids...).Result()
+}
+
+// sortMessages orders messages newest first by stream ID
+func sortMessages(messages []redis.XMessage) {
+	sort.Slice(messages, func(i, j int) bool {
+		ims, iseq := splitID(messages[i].ID)
+		jms, jseq := splitID(messages[j].ID)
+		if ims != jms {
+			return ims > jms
+		}
+		return iseq > jseq
+	})
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// splitID splits a <ms>-<seq> stream ID
+func splitID(id string) (int64, int64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	msValue, _ := strconv.ParseInt(ms, 10, 64)
+	seqValue, _ := strconv.ParseInt(seq, 10, 64)
+	return msValue, seqValue
+}
+
+func flagFromMessage(message redis.XMessage, pending bool) Flag {
+	field := func(name string) string {
+		v, _ := message.Values[name].(string)
+		return v
+	}
+	flag := Flag{
+		ID:          message.ID,
+		UserID:      field("user_id"),
+		VideoID:     field("video_id"),
+		Type:        field("type"),
+		Interaction: json.RawMessage(field("interaction")),
+		Pending:     pending,
+	}
+	if err := json.Unmarshal([]byte(field("decision")), &flag.Decision); err != nil {
+		flag.Decision = Decision{Action: ActionAllow, Weight: 1, Trust: DefaultTrust}
+	}
+	return flag
+}
@@ -0,0 +1,65 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TrustKey holds the trust factor of each user, users without one have DefaultTrust
+const TrustKey = "fraud:trust"
+
+// Trust bounds. Trust multiplies the score of every interaction of a user, it never reaches
+// zero so that scored interactions always carry a positive weight in the interaction log.
+const (
+	DefaultTrust = 1.0
+	MinTrust     = 0.1
+	MaxTrust     = 2.0
+)
+
+// adjustTrust adds a delta to the trust of a user within [ARGV[3], ARGV[4]] and returns the
+// trust before and after
+var adjustTrust = redis.NewScript(`
+local before = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or ARGV[5])
+local after = math.min(math.max(before + tonumber(ARGV[2]), tonumber(ARGV[3])), tonumber(ARGV[4]))
+redis.call('HSET', KEYS[1], ARGV[1], tostring(after))
+return {tostring(before), tostring(after)}
+`)
+
+// Trust returns the trust factor of a user
+func Trust(ctx context.Context, rdb *redis.Client, userID string) (float64, error) {
+	trust, err := rdb.HGet(ctx, TrustKey, userID).Float64()
+	if errors.Is(err, redis.Nil) {
+		return DefaultTrust, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return min(max(trust, MinTrust), MaxTrust), nil
+}
+
+// SetTrust replaces the trust factor of a user, clamped to the trust bounds, and returns
+// the previous one
+func SetTrust(ctx context.Context, rdb *redis.Client, userID string, trust float64) (float64, error) {
+	trust = min(max(trust, MinTrust), MaxTrust)
+	before, _, err := runTrust(ctx, rdb, userID, 0, trust, trust)
+	return before, err
+}
+
+// AdjustTrust adds a delta to the trust factor of a user within the trust bounds and returns
+// the trust before and after
+func AdjustTrust(ctx context.Context, rdb *redis.Client, userID string, delta float64) (float64, float64, error) {
+	return runTrust(ctx, rdb, userID, delta, MinTrust, MaxTrust)
+}
+
+func runTrust(ctx context.Context, rdb *redis.Client, userID string, delta, low, high float64) (float64, float64, error) {
+	values, err := adjustTrust.Run(ctx, rdb, []string{TrustKey}, userID, delta, low, high, DefaultTrust).StringSlice()
+	if err != nil {
+		return 0, 0, err
+	}
+	before, _ := strconv.ParseFloat(values[0], 64)
+	after, _ := strconv.ParseFloat(values[1], 64)
+	return before, after, nil
+}
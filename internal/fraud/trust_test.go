@@ -0,0 +1,44 @@
+package fraud
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrust(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	trust, err := Trust(ctx, client, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTrust, trust)
+
+	before, after, err := AdjustTrust(ctx, client, "user1", -0.25)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, before)
+	assert.Equal(t, 0.75, after)
+
+	before, after, err = AdjustTrust(ctx, client, "user1", -5)
+	require.NoError(t, err)
+	assert.Equal(t, 0.75, before)
+	assert.Equal(t, MinTrust, after)
+
+	before, err = SetTrust(ctx, client, "user1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, MinTrust, before)
+	trust, err = Trust(ctx, client, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, MaxTrust, trust)
+
+	assert.Equal(t, 0.75, Decision{Weight: 0.5, Trust: 1.5}.ScoreWeight())
+	assert.Equal(t, 1.0, Decide(nil, Event{}, Signals{}).ScoreWeight())
+
+	require.NoError(t, client.HSet(ctx, TrustKey, "user2", "0").Err())
+	trust, err = Trust(ctx, client, "user2")
+	require.NoError(t, err)
+	assert.Equal(t, MinTrust, trust)
+}
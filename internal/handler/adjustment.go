@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// adjustmentsKey holds the cumulative manual adjustment of each video, applied again on rebuilds
+const adjustmentsKey = "rankings:adjustments"
+
+// adjustWithFloor adds ARGV[1] to the score of video ARGV[2] on the global board, never below
+// ARGV[3], and applies the same delta to the creator board, the video hash and the adjustments.
+// It returns the global score before and after.
+var adjustWithFloor = redis.NewScript(`
+local before = tonumber(redis.call('ZSCORE', KEYS[1], ARGV[2])) or 0
+local after = math.max(before + tonumber(ARGV[1]), tonumber(ARGV[3]))
+local applied = after - before
+redis.call('ZADD', KEYS[1], after, ARGV[2])
+redis.call('ZINCRBY', KEYS[2], applied, ARGV[2])
+redis.call('HSET', KEYS[3], 'score', tostring(after))
+redis.call('ZINCRBY', KEYS[4], applied, ARGV[2])
+return {tostring(before), tostring(after)}
+`)
+
+type ScoreAdjustment struct {
+	Delta  float64 `json:"delta"`
+	Reason string  `json:"reason"`
+}
+
+// AdjustScore manually changes the score of a video
+//
+//	@Summary		Adjust a video score
+//	@Description	Add a delta to the score of a video on every board, recorded in the audit log with its reason
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string			true	"Video ID"
+//	@Param			adjustment	body		ScoreAdjustment	true	"Score delta and reason"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.AuditEntry}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		404			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/videos/{id}/score-adjustments [post]
+func (h *AdminHandler) AdjustScore(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoID := r.PathValue("id")
+
+	var adjustment ScoreAdjustment
+	if err := json.NewDecoder(r.Body).Decode(&adjustment); err != nil {
+		return ErrorInvalidRequestBody
+	}
+	if adjustment.Delta == 0 || adjustment.Reason == "" {
+		return ErrorInvalidAdjustment
+	}
+
+	videoKey := fmt.Sprintf("video:%s", videoID)
+	creatorID, err := h.redis.HGet(ctx, videoKey, "creator_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrorVideoNotFound
+	}
+	if err != nil {
+		h.logger.Error("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	// the creator board and the rebuilds follow the delta applied after the floor
+	keys := []string{"rankings:global", fmt.Sprintf("creator:%s:videos", creatorID), videoKey, adjustmentsKey}
+	scores, err := adjustWithFloor.Run(ctx, h.redis, keys, adjustment.Delta, videoID, ScoreFloor).StringSlice()
+	if err != nil {
+		h.logger.Error("failed to adjust video score", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	before, _ := strconv.ParseFloat(scores[0], 64)
+	after, _ := strconv.ParseFloat(scores[1], 64)
+
+	h.logger.Info("video score adjusted",
+		zap.String("video_id", videoID),
+		zap.Float64("before", before),
+		zap.Float64("after", after),
+		zap.String("reason", adjustment.Reason),
+	)
+	entry, err := h.record(r, ActionAdjustScore, AuditTargetVideo, videoID, adjustment.Reason,
+		map[string]float64{"score": before}, map[string]float64{"score": after})
+	if err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: entry,
+	})
+}
+
+// GetAuditLog returns the recorded admin actions
+//
+//	@Summary		Get the audit log
+//	@Description	List admin actions newest first, optionally for one video, creator, user or actor
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			video_id	query		string	false	"Video ID"
+//	@Param			creator_id	query		string	false	"Creator ID"
+//	@Param			user_id		query		string	false	"User ID"
+//	@Param			actor		query		string	false	"Actor"
+//	@Param			limit		query		int		false	"Number of entries to retrieve (default: 50)"
+//	@Success		200			{object}	httputil.HttpResponse{data=[]handler.AuditEntry}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/audit [get]
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) error {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 50
+	}
+	if limit > 1000 || limit < 1 {
+		return ErrorLimitRange
+	}
+
+	filter := AuditFilter{Actor: r.URL.Query().Get("actor"), Limit: int64(limit)}
+	if videoID := r.URL.Query().Get("video_id"); videoID != "" {
+		filter.TargetType, filter.TargetID = AuditTargetVideo, videoID
+	} else if creatorID := r.URL.Query().Get("creator_id"); creatorID != "" {
+		filter.TargetType, filter.TargetID = AuditTargetCreator, creatorID
+	} else if userID := r.URL.Query().Get("user_id"); userID != "" {
+		filter.TargetType, filter.TargetID = AuditTargetUser, userID
+	}
+
+	entries, err := h.audit.Query(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to query audit log", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: entries,
+	})
+}
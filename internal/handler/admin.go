@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"realtime_ranking/pkg/middleware"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	redis  *redis.Client
+	logger *zap.Logger
+	audit  *AuditLog
+	events *EventLog
+	// trustPenalty is removed from the trust of a user for each rejected interaction
+	trustPenalty float64
+	// ranking scores released interactions the way accepted ones are
+	ranking *RankingHandler
+}
+
+// Admin actions recorded in the audit log
+const (
+	ActionRepairRankings  = "repair_rankings"
+	ActionImportSnapshot  = "import_snapshot"
+	ActionClearReview     = "clear_review"
+	ActionTakedown        = "takedown"
+	ActionRestoreTakedown = "restore_takedown"
+	ActionShadowban       = "shadowban"
+	ActionLiftShadowban   = "lift_shadowban"
+	ActionPin             = "pin"
+	ActionUnpin           = "unpin"
+	ActionAdjustScore     = "adjust_score"
+
+	ActionReleaseInteraction = "release_interaction"
+	ActionRejectInteraction  = "reject_interaction"
+	ActionConfirmBot         = "confirm_bot"
+	ActionSetTrust           = "set_trust"
+	ActionPenalizeTrust      = "penalize_trust"
+
+	ActionMigrateSeen       = "migrate_seen"
+	ActionBackfillFollowers = "backfill_followers"
+)
+
+// FraudActor is the audit actor of the trust changes made by fraud detection
+const FraudActor = "fraud"
+
+// CLIActor prefixes the audit actor of the changes made with rankctl, followed by the
+// operator's login
+const CLIActor = "rankctl"
+
+// auditActor identifies who performs an admin request
+func auditActor(r *http.Request) string {
+	return middleware.PrincipalFrom(r.Context()).ID
+}
+
+// record appends an admin action to the audit log. The action already happened, a
+// failure to record it is reported as an update failure.
+func (h *AdminHandler) record(r *http.Request, action, targetType, targetID, reason string, before, after any) (AuditEntry, error) {
+	entry, err := h.audit.Record(r.Context(), auditActor(r), action, targetType, targetID, reason, before, after)
+	if err != nil {
+		h.logger.Error("failed to record admin action",
+			zap.String("action", action),
+			zap.String("target_id", targetID),
+			zap.Error(err),
+		)
+		return entry, ErrorUpdateDataFailed
+	}
+	return entry, nil
+}
+
+// VerifyRankings reports inconsistencies between video hashes and ranking sets
+//
+//	@Summary		Verify ranking consistency
+//	@Description	Compare video:<id>.score, rankings:global and creator rankings and report mismatches
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.VerifyReport}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/rankings/verify [get]
+func (h *AdminHandler) VerifyRankings(w http.ResponseWriter, r *http.Request) error {
+	report, err := VerifyRankings(r.Context(), h.redis, false)
+	if err != nil {
+		h.logger.Error("failed to verify rankings", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: report,
+	})
+}
+
+// RepairRankings verifies the ranking keys and rewrites the inconsistent ones
+//
+//	@Summary		Repair ranking consistency
+//	@Description	Verify the ranking keys and repair mismatches using rankings:global as the source of truth
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.VerifyReport}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/rankings/repair [post]
+func (h *AdminHandler) RepairRankings(w http.ResponseWriter, r *http.Request) error {
+	report, err := VerifyRankings(r.Context(), h.redis, true)
+	if err != nil {
+		h.logger.Error("failed to repair rankings", zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("rankings repaired", zap.Int("mismatches", len(report.Mismatches)))
+	if _, err := h.record(r, ActionRepairRankings, AuditTargetRanking, "", "", nil,
+		map[string]int{"videos": report.Videos, "mismatches": len(report.Mismatches)}); err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: report,
+	})
+}
+
+// ExportSnapshot streams the leaderboards and video hashes
+//
+//	@Summary		Export ranking snapshot
+//	@Description	Stream rankings:global, creator rankings and video hashes as JSONL or CSV
+//	@Tags			Admin
+//	@Produce		plain
+//	@Param			format	query		string	false	"jsonl (default) or csv"
+//	@Success		200		{string}	string	"snapshot records"
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		401		{object}	httputil.ErrorResponse
+//	@Failure		403		{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/snapshots/export [get]
+func (h *AdminHandler) ExportSnapshot(w http.ResponseWriter, r *http.Request) error {
+	format, err := ParseSnapshotFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/x-ndjson"
+	if format == SnapshotCSV {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="rankings.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+
+	// the status is already sent, failures can only be logged
+	if err := ExportSnapshot(r.Context(), h.redis, w, format); err != nil {
+		h.logger.Error("failed to export snapshot", zap.Error(err))
+	}
+	return nil
+}
+
+// ImportSnapshot restores a snapshot into an empty keyspace
+//
+//	@Summary		Import ranking snapshot
+//	@Description	Atomically restore a JSONL or CSV snapshot into an empty keyspace, optionally under a key prefix
+//	@Tags			Admin
+//	@Accept			plain
+//	@Produce		json
+//	@Param			format		query		string	false	"jsonl (default) or csv"
+//	@Param			prefix		query		string	false	"Key prefix to restore under"
+//	@Param			snapshot	body		string	true	"Snapshot records"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.ImportResult}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		409			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/snapshots/import [post]
+func (h *AdminHandler) ImportSnapshot(w http.ResponseWriter, r *http.Request) error {
+	format, err := ParseSnapshotFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return err
+	}
+	prefix := r.URL.Query().Get("prefix")
+
+	result, err := ImportSnapshot(r.Context(), h.redis, r.Body, format, prefix)
+	if errors.Is(err, ErrorSnapshotTargetNotEmpty) {
+		return ErrorSnapshotTargetNotEmpty
+	}
+	if err != nil {
+		h.logger.Error("failed to import snapshot", zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("snapshot imported", zap.String("prefix", prefix), zap.Int("keys", result.Keys))
+	if _, err := h.record(r, ActionImportSnapshot, AuditTargetRanking, prefix, "", nil, result); err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: result,
+	})
+}
+
+// NewAdminHandler sets up the administrative routes
+func NewAdminHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger, audit *AuditLog, events *EventLog, trustPenalty float64, ranking *RankingHandler) {
+	handler := &AdminHandler{
+		redis:        redis,
+		logger:       logger,
+		audit:        audit,
+		events:       events,
+		trustPenalty: trustPenalty,
+		ranking:      ranking,
+	}
+	admin := func(endpoint middleware.Endpoint) http.HandlerFunc {
+		return middleware.WithErrorHandler(middleware.RequireRole(middleware.RoleAdmin, endpoint), logger)
+	}
+	mux.HandleFunc("GET /api/v1/admin/rankings/verify", admin(handler.VerifyRankings))
+	mux.HandleFunc("POST /api/v1/admin/rankings/repair", admin(handler.RepairRankings))
+	mux.HandleFunc("GET /api/v1/admin/snapshots/export", admin(handler.ExportSnapshot))
+	mux.HandleFunc("POST /api/v1/admin/snapshots/import", admin(handler.ImportSnapshot))
+	mux.HandleFunc("GET /api/v1/admin/reviews", admin(handler.ListReviews))
+	mux.HandleFunc("DELETE /api/v1/admin/reviews/{id}", admin(handler.ClearReview))
+	mux.HandleFunc("GET /api/v1/admin/moderation", admin(handler.GetModeration))
+	mux.HandleFunc("POST /api/v1/admin/videos/{id}/takedown", admin(handler.Takedown))
+	mux.HandleFunc("DELETE /api/v1/admin/videos/{id}/takedown", admin(handler.RestoreTakedown))
+	mux.HandleFunc("POST /api/v1/admin/creators/{id}/shadowban", admin(handler.Shadowban))
+	mux.HandleFunc("DELETE /api/v1/admin/creators/{id}/shadowban", admin(handler.LiftShadowban))
+	mux.HandleFunc("POST /api/v1/admin/videos/{id}/pin", admin(handler.PinVideo))
+	mux.HandleFunc("DELETE /api/v1/admin/videos/{id}/pin", admin(handler.UnpinVideo))
+	mux.HandleFunc("POST /api/v1/admin/videos/{id}/score-adjustments", admin(handler.AdjustScore))
+	mux.HandleFunc("GET /api/v1/admin/audit", admin(handler.GetAuditLog))
+	mux.HandleFunc("GET /api/v1/admin/fraud/flags", admin(handler.ListFlags))
+	mux.HandleFunc("POST /api/v1/admin/fraud/flags/{id}/release", admin(handler.ReleaseFlag))
+	mux.HandleFunc("DELETE /api/v1/admin/fraud/flags/{id}", admin(handler.RejectFlag))
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/bot", admin(handler.ConfirmBot))
+	mux.HandleFunc("GET /api/v1/admin/users/{id}/trust", admin(handler.GetTrust))
+	mux.HandleFunc("PUT /api/v1/admin/users/{id}/trust", admin(handler.SetTrust))
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Analytics granularities, with the time buckets and retention of their counters
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+var granularities = map[string]struct {
+	bucket    time.Duration
+	retention time.Duration
+}{
+	GranularityHour: {bucket: time.Hour, retention: 31 * 24 * time.Hour},
+	GranularityDay:  {bucket: 24 * time.Hour, retention: 400 * 24 * time.Hour},
+}
+
+// maxAnalyticsPoints bounds the time series of an analytics request
+const maxAnalyticsPoints = 1000
+
+// recordBestRank keeps the best 1-based rank a video reached on a board and returns its rank
+var recordBestRank = redis.NewScript(`
+local rank = redis.call('ZREVRANK', KEYS[1], ARGV[1])
+if not rank then
+	return false
+end
+rank = rank + 1
+local best = tonumber(redis.call('HGET', KEYS[2], ARGV[1]))
+if not best or rank < best then
+	redis.call('HSET', KEYS[2], ARGV[1], rank)
+end
+return rank
+`)
+
+func creatorActivityKey(creatorID, granularity string, bucket int64) string {
+	return fmt.Sprintf("creator:%s:activity:%s:%d", creatorID, granularity, bucket)
+}
+
+func creatorBestRanksKey(creatorID string) string {
+	return fmt.Sprintf("creator:%s:best_ranks", creatorID)
+}
+
+func creatorFollowersKey(creatorID string) string {
+	return fmt.Sprintf("creator:%s:followers", creatorID)
+}
+
+// bucketStart returns the start of the bucket holding a unix timestamp
+func bucketStart(timestamp int64, bucket time.Duration) int64 {
+	size := int64(bucket.Seconds())
+	return timestamp - timestamp%size
+}
+
+// incrActivity adds counters to the hourly and daily buckets of a creator at now
+func incrActivity(ctx context.Context, pipe redis.Pipeliner, creatorID string, now time.Time, counters map[string]int64, score float64) {
+	for granularity, config := range granularities {
+		key := creatorActivityKey(creatorID, granularity, bucketStart(now.Unix(), config.bucket))
+		for field, value := range counters {
+			pipe.HIncrBy(ctx, key, field, value)
+		}
+		if score != 0 {
+			pipe.HIncrByFloat(ctx, key, "score", score)
+		}
+		pipe.Expire(ctx, key, config.retention)
+	}
+}
+
+// recordCreatorActivity counts a scored interaction in the activity of the creator of its
+// video and keeps the best global rank the video reached
+func recordCreatorActivity(ctx context.Context, rdb *redis.Client, creatorID string, interaction Interaction, increment float64, now time.Time) error {
+	pipe := rdb.Pipeline()
+	incrActivity(ctx, pipe, creatorID, now, map[string]int64{interaction.Type: 1}, increment)
+	recordBestRank.Eval(ctx, pipe, []string{"rankings:global", creatorBestRanksKey(creatorID)}, interaction.VideoID)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+// recordFollow keeps the followers of a creator and counts the change in their activity
+func recordFollow(ctx context.Context, rdb *redis.Client, userID, creatorID string, following bool, now time.Time) error {
+	var changed int64
+	var err error
+	if following {
+		changed, err = rdb.SAdd(ctx, creatorFollowersKey(creatorID), userID).Result()
+	} else {
+		changed, err = rdb.SRem(ctx, creatorFollowersKey(creatorID), userID).Result()
+	}
+	if err != nil || changed == 0 {
+		return err
+	}
+	field := "follows"
+	if !following {
+		field = "unfollows"
+	}
+	pipe := rdb.Pipeline()
+	incrActivity(ctx, pipe, creatorID, now, map[string]int64{field: 1}, 0)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// BackfillResult counts the follows copied into the creator follower sets
+type BackfillResult struct {
+	Users   int `json:"users"`
+	Follows int `json:"follows"`
+}
+
+// BackfillFollowers adds every user to the follower sets of the creators in their
+// user:<id>:follows set, for the follows made before the follower sets existed. It is
+// idempotent and leaves the activity counters alone.
+func BackfillFollowers(ctx context.Context, rdb *redis.Client) (BackfillResult, error) {
+	var result BackfillResult
+	iter := rdb.Scan(ctx, 0, "user:*:follows", 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		userID := strings.TrimSuffix(strings.TrimPrefix(key, "user:"), ":follows")
+		creatorIDs, err := rdb.SMembers(ctx, key).Result()
+		if err != nil {
+			return result, fmt.Errorf("read %s: %w", key, err)
+		}
+		pipe := rdb.Pipeline()
+		for _, creatorID := range creatorIDs {
+			pipe.SAdd(ctx, creatorFollowersKey(creatorID), userID)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return result, fmt.Errorf("backfill %s: %w", key, err)
+		}
+		result.Users++
+		result.Follows += len(creatorIDs)
+	}
+	return result, iter.Err()
+}
+
+// AnalyticsPoint is the activity of a creator during one time bucket
+type AnalyticsPoint struct {
+	Timestamp int64 `json:"timestamp"`
+	// Interactions counts the scored interactions by type
+	Interactions map[string]int64 `json:"interactions"`
+	Score        float64          `json:"score"`
+	Follows      int64            `json:"follows"`
+	Unfollows    int64            `json:"unfollows"`
+}
+
+// creatorActivity reads the activity buckets of a creator within [from, to]
+func creatorActivity(ctx context.Context, rdb *redis.Client, creatorID, granularity string, from, to int64) ([]AnalyticsPoint, error) {
+	size := int64(granularities[granularity].bucket.Seconds())
+	pipe := rdb.Pipeline()
+	var cmds []*redis.MapStringStringCmd
+	var timestamps []int64
+	for bucket := bucketStart(from, granularities[granularity].bucket); bucket <= to; bucket += size {
+		timestamps = append(timestamps, bucket)
+		cmds = append(cmds, pipe.HGetAll(ctx, creatorActivityKey(creatorID, granularity, bucket)))
+	}
+	if len(cmds) == 0 {
+		return []AnalyticsPoint{}, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	points := make([]AnalyticsPoint, len(cmds))
+	for i, cmd := range cmds {
+		point := AnalyticsPoint{Timestamp: timestamps[i], Interactions: map[string]int64{}}
+		for field, value := range cmd.Val() {
+			switch field {
+			case "score":
+				point.Score, _ = strconv.ParseFloat(value, 64)
+			case "follows":
+				point.Follows, _ = strconv.ParseInt(value, 10, 64)
+			case "unfollows":
+				point.Unfollows, _ = strconv.ParseInt(value, 10, 64)
+			default:
+				point.Interactions[field], _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+		points[i] = point
+	}
+	return points, nil
+}
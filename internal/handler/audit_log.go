@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const auditLogKey = "audit:log"
+
+// Targets of admin actions
+const (
+	AuditTargetVideo   = "video"
+	AuditTargetCreator = "creator"
+	AuditTargetUser    = "user"
+	AuditTargetRanking = "rankings"
+)
+
+// AuditEntry records one admin action
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	Reason     string          `json:"reason,omitempty"`
+	Timestamp  int64           `json:"timestamp"`
+}
+
+// AuditFilter selects audit entries. Empty fields match everything.
+type AuditFilter struct {
+	TargetType string
+	TargetID   string
+	Actor      string
+	Limit      int64
+}
+
+// AuditLog is an append-only trail of admin actions backed by a Redis Stream, indexed
+// by target and by actor.
+type AuditLog struct {
+	redis *redis.Client
+	now   func() time.Time
+}
+
+func NewAuditLog(redis *redis.Client) *AuditLog {
+	return &AuditLog{
+		redis: redis,
+		now:   time.Now,
+	}
+}
+
+func auditTargetKey(targetType, targetID string) string {
+	return fmt.Sprintf("audit:%s:%s", targetType, targetID)
+}
+
+func auditActorKey(actor string) string {
+	return fmt.Sprintf("audit:actor:%s", actor)
+}
+
+// Record appends an admin action with the JSON encoding of the before and after values
+func (l *AuditLog) Record(ctx context.Context, actor, action, targetType, targetID, reason string, before, after any) (AuditEntry, error) {
+	entry := AuditEntry{
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Timestamp:  l.now().Unix(),
+	}
+	var err error
+	if entry.Before, err = marshalAuditValue(before); err != nil {
+		return entry, err
+	}
+	if entry.After, err = marshalAuditValue(after); err != nil {
+		return entry, err
+	}
+
+	entry.ID, err = l.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: auditLogKey,
+		Values: []string{
+			"actor", entry.Actor,
+			"action", entry.Action,
+			"target_type", entry.TargetType,
+			"target_id", entry.TargetID,
+			"before", string(entry.Before),
+			"after", string(entry.After),
+			"reason", entry.Reason,
+			"timestamp", strconv.FormatInt(entry.Timestamp, 10),
+		},
+	}).Result()
+	if err != nil {
+		return entry, err
+	}
+
+	member := redis.Z{Score: float64(entry.Timestamp), Member: entry.ID}
+	_, err = l.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if targetID != "" {
+			pipe.ZAdd(ctx, auditTargetKey(targetType, targetID), member)
+		}
+		pipe.ZAdd(ctx, auditActorKey(actor), member)
+		return nil
+	})
+	return entry, err
+}
+
+// Query returns the matching entries, newest first
+func (l *AuditLog) Query(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var index string
+	switch {
+	case filter.TargetID != "":
+		index = auditTargetKey(filter.TargetType, filter.TargetID)
+	case filter.Actor != "":
+		index = auditActorKey(filter.Actor)
+	default:
+		messages, err := l.redis.XRevRangeN(ctx, auditLogKey, "+", "-", filter.Limit).Result()
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]AuditEntry, len(messages))
+		for i, message := range messages {
+			entries[i] = auditEntryFromMessage(message)
+		}
+		return entries, nil
+	}
+
+	// entries are read newest first from the index until the limit of matches
+	entries := []AuditEntry{}
+	for offset := int64(0); int64(len(entries)) < filter.Limit; offset += replayBatchSize {
+		ids, err := l.redis.ZRevRange(ctx, index, offset, offset+replayBatchSize-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			messages, err := l.redis.XRange(ctx, auditLogKey, id, id).Result()
+			if err != nil {
+				return nil, err
+			}
+			if len(messages) == 0 {
+				continue
+			}
+			entry := auditEntryFromMessage(messages[0])
+			if filter.Actor != "" && entry.Actor != filter.Actor {
+				continue
+			}
+			entries = append(entries, entry)
+			if int64(len(entries)) == filter.Limit {
+				break
+			}
+		}
+		if len(ids) < replayBatchSize {
+			break
+		}
+	}
+	return entries, nil
+}
+
+// marshalAuditValue encodes a before or after value, leaving absent values empty
+func marshalAuditValue(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil || string(raw) == "null" {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func auditEntryFromMessage(message redis.XMessage) AuditEntry {
+	field := func(name string) string {
+		v, _ := message.Values[name].(string)
+		return v
+	}
+	timestamp, _ := strconv.ParseInt(field("timestamp"), 10, 64)
+	entry := AuditEntry{
+		ID:         message.ID,
+		Actor:      field("actor"),
+		Action:     field("action"),
+		TargetType: field("target_type"),
+		TargetID:   field("target_id"),
+		Reason:     field("reason"),
+		Timestamp:  timestamp,
+	}
+	if before := field("before"); before != "" {
+		entry.Before = json.RawMessage(before)
+	}
+	if after := field("after"); after != "" {
+		entry.After = json.RawMessage(after)
+	}
+	return entry
+}
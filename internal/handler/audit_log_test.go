@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/pkg/middleware"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// asAdmin authenticates a request as an admin
+func asAdmin(req *http.Request, id string) *http.Request {
+	principal := middleware.Principal{ID: id, Role: middleware.RoleAdmin, Method: middleware.AuthAPIKey}
+	return req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+}
+
+func asService(req *http.Request) *http.Request {
+	principal := middleware.Principal{ID: "backend", Role: middleware.RoleIngest, Method: middleware.AuthAPIKey, Service: true}
+	return req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+}
+
+func TestAuditLog(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	admin := &AdminHandler{redis: handler.redis, logger: logger, audit: NewAuditLog(handler.redis)}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+
+	adjust := func(t *testing.T, actor string, adjustment ScoreAdjustment) (*httptest.ResponseRecorder, error) {
+		body, _ := json.Marshal(adjustment)
+		req, err := http.NewRequest("POST", "/api/v1/admin/videos/video1/score-adjustments", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.SetPathValue("id", "video1")
+		req = asAdmin(req, actor)
+		rr := httptest.NewRecorder()
+		return rr, admin.AdjustScore(rr, req)
+	}
+	query := func(t *testing.T, params string) []AuditEntry {
+		req, err := http.NewRequest("GET", "/api/v1/admin/audit?"+params, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, admin.GetAuditLog(rr, req))
+		var response struct {
+			Data []AuditEntry `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data
+	}
+
+	t.Run("score adjustment", func(t *testing.T) {
+		rr, err := adjust(t, "alice", ScoreAdjustment{Delta: 15, Reason: "editorial boost"})
+		require.NoError(t, err)
+
+		var response struct {
+			Data AuditEntry `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, "alice", response.Data.Actor)
+		assert.Equal(t, ActionAdjustScore, response.Data.Action)
+		assert.JSONEq(t, `{"score":10}`, string(response.Data.Before))
+		assert.JSONEq(t, `{"score":25}`, string(response.Data.After))
+
+		score, err := mr.ZScore("creator:creator1:videos", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, 25.0, score)
+		assert.Equal(t, "25", mr.HGet("video:video1", "score"))
+	})
+
+	t.Run("adjustment floor", func(t *testing.T) {
+		_, err := adjust(t, "bob", ScoreAdjustment{Delta: -100, Reason: "spam"})
+		require.NoError(t, err)
+		score, err := mr.ZScore("rankings:global", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, ScoreFloor, score)
+		adjustment, err := mr.ZScore(adjustmentsKey, "video1")
+		require.NoError(t, err)
+		assert.Equal(t, -10.0, adjustment)
+	})
+
+	t.Run("invalid adjustment", func(t *testing.T) {
+		_, err := adjust(t, "bob", ScoreAdjustment{Delta: 5})
+		assert.Equal(t, ErrorInvalidAdjustment, err)
+	})
+
+	t.Run("admin actions are audited", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/v1/admin/videos/video1/takedown", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video1")
+		req = asAdmin(req, "alice")
+		require.NoError(t, admin.Takedown(httptest.NewRecorder(), req))
+
+		entries := query(t, "video_id=video1")
+		require.Len(t, entries, 3)
+		assert.Equal(t, ActionTakedown, entries[0].Action)
+		assert.JSONEq(t, `{"takedown":false}`, string(entries[0].Before))
+		assert.JSONEq(t, `{"takedown":true}`, string(entries[0].After))
+
+		entries = query(t, "actor=alice")
+		require.Len(t, entries, 2)
+		assert.Equal(t, ActionTakedown, entries[0].Action)
+		assert.Equal(t, ActionAdjustScore, entries[1].Action)
+		assert.Equal(t, "editorial boost", entries[1].Reason)
+
+		assert.Len(t, query(t, "video_id=video1&actor=bob"), 1)
+		assert.Len(t, query(t, "limit=1"), 1)
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"realtime_ranking/pkg/middleware"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type CreatorHandler struct {
+	redis  *redis.Client
+	logger *zap.Logger
+}
+
+// CreatorAnalytics is the dashboard of a creator
+type CreatorAnalytics struct {
+	CreatorID  string  `json:"creator_id"`
+	TotalScore float64 `json:"total_score"`
+	Followers  int64   `json:"followers"`
+	// BestRank is the best global rank any video of the creator reached
+	BestRank    *int64           `json:"best_rank,omitempty"`
+	Videos      []VideoAnalytics `json:"videos"`
+	Granularity string           `json:"granularity"`
+	Series      []AnalyticsPoint `json:"series"`
+}
+
+// VideoAnalytics is the breakdown of one video of a creator
+type VideoAnalytics struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Score    float64    `json:"score"`
+	BestRank *int64     `json:"best_rank,omitempty"`
+	Stats    VideoStats `json:"stats"`
+}
+
+// creatorAccess lets creators read their own analytics, along with services and admins
+func creatorAccess(r *http.Request, creatorID string) error {
+	principal := middleware.PrincipalFrom(r.Context())
+	if principal.Method == middleware.AuthAnonymous {
+		return middleware.ErrorAuthenticationRequired
+	}
+	if !principal.ActsFor(creatorID) && !principal.Role.Includes(middleware.RoleAdmin) {
+		return ErrorCreatorMismatch
+	}
+	return nil
+}
+
+// GetAnalytics returns the dashboard of a creator
+//
+//	@Summary		Get creator analytics
+//	@Description	Retrieve the total score, followers, best global rank and per-video breakdown of a creator, with the interactions, score gained and follows per time bucket.
+//	@Description	Only the creator, service principals and admins can read them.
+//	@Tags			Creator
+//	@Produce		json
+//	@Param			id			path		string	true	"Creator ID"
+//	@Param			granularity	query		string	false	"hour or day (default)"
+//	@Param			from		query		int		false	"Start unix timestamp (default: 30 days or 48 hours before to)"
+//	@Param			to			query		int		false	"End unix timestamp (default: now)"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.CreatorAnalytics}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/creators/{id}/analytics [get]
+func (h *CreatorHandler) GetAnalytics(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	creatorID := r.PathValue("id")
+	if err := creatorAccess(r, creatorID); err != nil {
+		return err
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	config, ok := granularities[granularity]
+	if !ok {
+		return ErrorInvalidGranularity
+	}
+	window := 30 * 24 * time.Hour
+	if granularity == GranularityHour {
+		window = 48 * time.Hour
+	}
+	from, to, err := parseTimeRange(r, window)
+	if err != nil {
+		return err
+	}
+	if (to-bucketStart(from, config.bucket))/int64(config.bucket.Seconds()) >= maxAnalyticsPoints {
+		return ErrorAnalyticsRange
+	}
+
+	entries, err := h.redis.ZRevRangeWithScores(ctx, fmt.Sprintf("creator:%s:videos", creatorID), 0, -1).Result()
+	if err != nil {
+		h.logger.Info("failed to get creator videos", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	videoIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		videoIDs[i] = entry.Member.(string)
+	}
+
+	pipe := h.redis.Pipeline()
+	titleCmds := make([]*redis.StringCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		titleCmds[i] = pipe.HGet(ctx, fmt.Sprintf("video:%s", videoID), "title")
+	}
+	bestCmd := pipe.HGetAll(ctx, creatorBestRanksKey(creatorID))
+	followersCmd := pipe.SCard(ctx, creatorFollowersKey(creatorID))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		h.logger.Info("failed to get creator data", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	stats, err := videoStats(ctx, h.redis, videoIDs)
+	if err != nil {
+		h.logger.Info("failed to get video stats", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	series, err := creatorActivity(ctx, h.redis, creatorID, granularity, from, to)
+	if err != nil {
+		h.logger.Info("failed to get creator activity", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+
+	analytics := CreatorAnalytics{
+		CreatorID:   creatorID,
+		Followers:   followersCmd.Val(),
+		Videos:      make([]VideoAnalytics, len(entries)),
+		Granularity: granularity,
+		Series:      series,
+	}
+	bestRanks := bestCmd.Val()
+	for i, entry := range entries {
+		stats[i].VideoID = ""
+		video := VideoAnalytics{
+			ID:    videoIDs[i],
+			Title: titleCmds[i].Val(),
+			Score: entry.Score,
+			Stats: stats[i],
+		}
+		if best, err := strconv.ParseInt(bestRanks[videoIDs[i]], 10, 64); err == nil {
+			video.BestRank = &best
+			if analytics.BestRank == nil || best < *analytics.BestRank {
+				analytics.BestRank = &best
+			}
+		}
+		analytics.TotalScore += entry.Score
+		analytics.Videos[i] = video
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: analytics,
+	})
+}
+
+// NewCreatorHandler sets up the per-creator routes
+func NewCreatorHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger) {
+	handler := &CreatorHandler{
+		redis:  redis,
+		logger: logger,
+	}
+	mux.HandleFunc("GET /api/v1/creators/{id}/analytics", middleware.WithErrorHandler(handler.GetAnalytics, logger))
+	mux.HandleFunc("GET /api/v1/creators/{id}/fans", middleware.WithErrorHandler(handler.GetFans, logger))
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/pkg/middleware"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatorAnalytics(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	creators := &CreatorHandler{redis: handler.redis, logger: logger}
+	users := &UserHandler{redis: handler.redis, logger: logger, personal: handler.personal}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "100")
+	mr.ZAdd("rankings:global", 100, "video3")
+	mr.ZAdd("creator:creator2:videos", 100, "video3")
+
+	interactions := []Interaction{
+		{VideoID: "video1", Type: InteractionLike, UserID: "user1"},
+		{VideoID: "video1", Type: InteractionView, UserID: "user2"},
+		{VideoID: "video2", Type: InteractionView, UserID: "user1"},
+	}
+	for _, interaction := range interactions {
+		interaction.Timestamp = 1690000000
+		body, _ := json.Marshal(interaction)
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, handler.UpdateScore(httptest.NewRecorder(), asService(req)))
+	}
+	// video1 reaches the top of the global board before video3 takes it back
+	mr.ZAdd("rankings:global", 1, "video3")
+	require.NoError(t, recordCreatorActivity(context.Background(), handler.redis, "creator1", interactions[1], 0, time.Now()))
+	mr.ZAdd("rankings:global", 100, "video3")
+
+	follow := func(userID string, following bool) {
+		method := "POST"
+		if !following {
+			method = "DELETE"
+		}
+		req, err := http.NewRequest(method, "/api/v1/users/"+userID+"/follows/creator1", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", userID)
+		req.SetPathValue("creator_id", "creator1")
+		require.NoError(t, users.setFollow(httptest.NewRecorder(), asService(req), following))
+	}
+	follow("user1", true)
+	follow("user1", true)
+	follow("user2", true)
+	follow("user2", false)
+
+	analytics := func(t *testing.T, principal middleware.Principal, query string) (CreatorAnalytics, error) {
+		req, err := http.NewRequest("GET", "/api/v1/creators/creator1/analytics?"+query, nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "creator1")
+		req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+		rr := httptest.NewRecorder()
+		if err := creators.GetAnalytics(rr, req); err != nil {
+			return CreatorAnalytics{}, err
+		}
+		var response struct {
+			Data CreatorAnalytics `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data, nil
+	}
+	creator := middleware.Principal{ID: "creator1", Role: middleware.RolePublic, Method: middleware.AuthJWT}
+
+	t.Run("daily", func(t *testing.T) {
+		result, err := analytics(t, creator, "")
+		require.NoError(t, err)
+		assert.Equal(t, "creator1", result.CreatorID)
+		assert.Equal(t, 7.0, result.TotalScore)
+		assert.Equal(t, int64(1), result.Followers)
+		require.NotNil(t, result.BestRank)
+		assert.Equal(t, int64(1), *result.BestRank)
+
+		require.Len(t, result.Videos, 2)
+		assert.Equal(t, "video1", result.Videos[0].ID)
+		assert.Equal(t, "Video One", result.Videos[0].Title)
+		assert.Equal(t, 6.0, result.Videos[0].Score)
+		assert.Equal(t, int64(1), *result.Videos[0].BestRank)
+		assert.Equal(t, VideoStats{Views: 1, Likes: 1, UniqueViewers: 1}, result.Videos[0].Stats)
+		assert.Equal(t, int64(3), *result.Videos[1].BestRank)
+
+		assert.Equal(t, GranularityDay, result.Granularity)
+		assert.Len(t, result.Series, 31)
+		today := result.Series[len(result.Series)-1]
+		assert.Equal(t, map[string]int64{InteractionLike: 1, InteractionView: 3}, today.Interactions)
+		assert.Equal(t, 7.0, today.Score)
+		assert.Equal(t, int64(2), today.Follows)
+		assert.Equal(t, int64(1), today.Unfollows)
+		assert.Empty(t, result.Series[0].Interactions)
+	})
+
+	t.Run("hourly", func(t *testing.T) {
+		now := time.Now().Unix()
+		result, err := analytics(t, creator, "granularity=hour&from="+itoa(now-3600)+"&to="+itoa(now))
+		require.NoError(t, err)
+		require.Len(t, result.Series, 2)
+		assert.Equal(t, int64(2), result.Series[1].Follows)
+
+		_, err = analytics(t, creator, "granularity=hour&from=0")
+		assert.ErrorIs(t, err, ErrorAnalyticsRange)
+		_, err = analytics(t, creator, "granularity=week")
+		assert.ErrorIs(t, err, ErrorInvalidGranularity)
+	})
+
+	t.Run("access", func(t *testing.T) {
+		_, err := analytics(t, middleware.Anonymous, "")
+		assert.ErrorIs(t, err, middleware.ErrorAuthenticationRequired)
+		_, err = analytics(t, middleware.Principal{ID: "creator2", Role: middleware.RolePublic, Method: middleware.AuthJWT}, "")
+		assert.ErrorIs(t, err, ErrorCreatorMismatch)
+		_, err = analytics(t, middleware.Principal{ID: "alice", Role: middleware.RoleAdmin, Method: middleware.AuthAPIKey}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("backfill followers", func(t *testing.T) {
+		// follows made before the follower sets existed
+		mr.SAdd("user:user3:follows", "creator1", "creator2")
+		result, err := BackfillFollowers(context.Background(), handler.redis)
+		require.NoError(t, err)
+		assert.Equal(t, BackfillResult{Users: 2, Follows: 3}, result)
+
+		analytics, err := analytics(t, creator, "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), analytics.Followers)
+		assert.Equal(t, int64(2), analytics.Series[len(analytics.Series)-1].Follows)
+		followers, err := mr.SMembers("creator:creator2:followers")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"user3"}, followers)
+	})
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"realtime_ranking/internal/fraud"
+	"realtime_ranking/internal/moderation"
 	"realtime_ranking/pkg/httputil"
 )
 
@@ -46,6 +49,10 @@ var (
 		Code: http.StatusBadRequest,
 		Err:  errors.New("user_id is required"),
 	}
+	ErrorUserMismatch = RankingError{
+		Code: http.StatusForbidden,
+		Err:  errors.New("user_id does not match the authenticated user"),
+	}
 	ErrorLimitRange = RankingError{
 		Code: http.StatusBadRequest,
 		Err:  errors.New("limit must be between 1 and 100"),
@@ -54,12 +61,92 @@ var (
 		Code: http.StatusBadRequest,
 		Err:  errors.New("offset must be greater than 0"),
 	}
+	ErrorPageDepth = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  fmt.Errorf("offset+limit must be at most %d", moderation.MaxDepth),
+	}
 	ErrorInvalidTimestamp = RankingError{
 		Code: http.StatusBadRequest,
 		Err:  errors.New("invalid timestamp"),
 	}
+	ErrorInvalidWatchTime = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("watch_time must not be negative nor exceed the video duration plus tolerance"),
+	}
+	ErrorInvalidDuration = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("duration must be a positive number of seconds"),
+	}
 	ErrorInvalidVideoID = RankingError{
 		Code: http.StatusBadRequest,
 		Err:  errors.New("invalid video_id"),
 	}
+	ErrorInvalidSnapshotFormat = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("format must be jsonl or csv"),
+	}
+	ErrorSnapshotTargetNotEmpty = RankingError{
+		Code: http.StatusConflict,
+		Err:  errors.New("import target keyspace is not empty"),
+	}
+	ErrorVideoNotFound = RankingError{
+		Code: http.StatusNotFound,
+		Err:  errors.New("video not found"),
+	}
+	ErrorInvalidTimeRange = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("from and to must be unix timestamps with from <= to"),
+	}
+	ErrorInvalidGranularity = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("granularity must be hour or day"),
+	}
+	ErrorAnalyticsRange = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  fmt.Errorf("from and to must span at most %d buckets", maxAnalyticsPoints),
+	}
+	ErrorCreatorMismatch = RankingError{
+		Code: http.StatusForbidden,
+		Err:  errors.New("analytics are only available to the creator"),
+	}
+	ErrorInvalidBoard = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("board must be global or creator"),
+	}
+	ErrorUnknownStrategy = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("unknown ranking strategy"),
+	}
+	ErrorInvalidCursor = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("cursor is invalid or expired"),
+	}
+	ErrorNotUnderReview = RankingError{
+		Code: http.StatusNotFound,
+		Err:  errors.New("video is not pending review"),
+	}
+	ErrorFlagNotPending = RankingError{
+		Code: http.StatusNotFound,
+		Err:  errors.New("interaction is not pending review"),
+	}
+	ErrorAlreadyBot = RankingError{
+		Code: http.StatusConflict,
+		Err:  errors.New("user is already confirmed as a bot"),
+	}
+	ErrorReleaseBot = RankingError{
+		Code: http.StatusConflict,
+		Err:  errors.New("interactions of users confirmed as bots cannot be released"),
+	}
+	ErrorInvalidPin = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("pin position must be positive and ttl a positive duration"),
+	}
+	ErrorInvalidAdjustment = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  errors.New("adjustment needs a non-zero delta and a reason"),
+	}
+	ErrorInvalidTrust = RankingError{
+		Code: http.StatusBadRequest,
+		Err:  fmt.Errorf("trust must be between %g and %g with a reason", fraud.MinTrust, fraud.MaxTrust),
+	}
 )
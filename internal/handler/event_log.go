@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	interactionLogKey = "interactions:log"
+	// interactionLogAddedKey counts every entry ever appended, trimming leaves it as is
+	interactionLogAddedKey = "interactions:log:added"
+)
+
+// ErrLogTrimmed is returned when the interaction log no longer holds every appended entry
+var ErrLogTrimmed = errors.New("interaction log has been trimmed")
+
+// replayBatchSize is the number of stream entries read per XRANGE call
+const replayBatchSize = 1000
+
+// EventLog is an append-only log of accepted interactions backed by a Redis Stream.
+// It is the source of truth the derived ranking keys can be rebuilt from.
+type EventLog struct {
+	redis  *redis.Client
+	maxLen int64
+}
+
+// NewEventLog creates an event log. A positive maxLen trims the stream
+// approximately to that many entries; zero keeps the full history. A trimmed
+// log can no longer be rebuilt from.
+func NewEventLog(redis *redis.Client, maxLen int64) *EventLog {
+	return &EventLog{
+		redis:  redis,
+		maxLen: maxLen,
+	}
+}
+
+// Append persists an interaction to the log
+func (l *EventLog) Append(ctx context.Context, interaction Interaction) error {
+	args := &redis.XAddArgs{
+		Stream: interactionLogKey,
+		Values: []string{
+			"video_id", interaction.VideoID,
+			"type", interaction.Type,
+			"user_id", interaction.UserID,
+			"timestamp", strconv.FormatInt(interaction.Timestamp, 10),
+			"watch_time", strconv.FormatInt(interaction.WatchTime, 10),
+		},
+	}
+	if interaction.Weight != 0 {
+		args.Values = append(args.Values.([]string), "weight", strconv.FormatFloat(interaction.Weight, 'f', -1, 64))
+	}
+	if interaction.Completion != 0 {
+		args.Values = append(args.Values.([]string),
+			"completion", strconv.FormatFloat(interaction.Completion, 'f', -1, 64),
+			"rewatch", strconv.FormatInt(interaction.Rewatch, 10),
+		)
+	}
+	if l.maxLen > 0 {
+		args.MaxLen = l.maxLen
+		args.Approx = true
+	}
+	_, err := l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, args)
+		pipe.Incr(ctx, interactionLogAddedKey)
+		return nil
+	})
+	return err
+}
+
+// Trimmed reports whether entries were trimmed off the log, comparing its length with
+// the number of entries appended. Entries appended before the count was kept are not
+// counted, so trimming among them goes unnoticed.
+func (l *EventLog) Trimmed(ctx context.Context) (bool, error) {
+	var length *redis.IntCmd
+	var added *redis.StringCmd
+	_, err := l.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		length = pipe.XLen(ctx, interactionLogKey)
+		added = pipe.Get(ctx, interactionLogAddedKey)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+	count, err := added.Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return count > length.Val(), nil
+}
+
+// Replay calls fn for every logged interaction in insertion order
+func (l *EventLog) Replay(ctx context.Context, fn func(Interaction) error) error {
+	start := "-"
+	for {
+		messages, err := l.redis.XRangeN(ctx, interactionLogKey, start, "+", replayBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		for _, message := range messages {
+			if err := fn(interactionFromMessage(message)); err != nil {
+				return err
+			}
+		}
+		if len(messages) < replayBatchSize {
+			return nil
+		}
+		// exclusive range start, continue after the last entry read
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+func interactionFromMessage(message redis.XMessage) Interaction {
+	field := func(name string) string {
+		v, _ := message.Values[name].(string)
+		return v
+	}
+	timestamp, _ := strconv.ParseInt(field("timestamp"), 10, 64)
+	watchTime, _ := strconv.ParseInt(field("watch_time"), 10, 64)
+	// full weight interactions are logged without weight, and all but watches without completion
+	weight, _ := strconv.ParseFloat(field("weight"), 64)
+	completion, _ := strconv.ParseFloat(field("completion"), 64)
+	rewatch, _ := strconv.ParseInt(field("rewatch"), 10, 64)
+	return Interaction{
+		VideoID:    field("video_id"),
+		Type:       field("type"),
+		UserID:     field("user_id"),
+		Timestamp:  timestamp,
+		WatchTime:  watchTime,
+		Weight:     weight,
+		Completion: completion,
+		Rewatch:    rewatch,
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// usersRankingKey ranks users by the score they contributed to all videos
+const usersRankingKey = "rankings:users"
+
+// creatorFansKey ranks users by the score they contributed to the videos of a creator
+func creatorFansKey(creatorID string) string {
+	return fmt.Sprintf("creator:%s:fans", creatorID)
+}
+
+// userContributionsKey indexes the score a user contributed to each video and
+// userContributionCountKey the number of scored interactions behind it, so that the
+// interactions of a bot are removed without replaying the interaction log
+func userContributionsKey(userID string) string {
+	return fmt.Sprintf("user:%s:contributions", userID)
+}
+
+func userContributionCountKey(userID string) string {
+	return fmt.Sprintf("user:%s:contributions:count", userID)
+}
+
+// removeContribution subtracts what a user contributed to a video from the global and creator
+// boards, never below ARGV[2], and consumes the contribution so that it is removed once.
+// It returns the contribution and the new global score, or false when there was none.
+var removeContribution = redis.NewScript(`
+local contribution = tonumber(redis.call('HGET', KEYS[1], ARGV[1]))
+if not contribution then
+	return false
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+local floor = tonumber(ARGV[2])
+local score = tonumber(redis.call('ZINCRBY', KEYS[2], -contribution, ARGV[1]))
+if score < floor then
+	score = floor
+	redis.call('ZADD', KEYS[2], score, ARGV[1])
+end
+local creatorScore = tonumber(redis.call('ZINCRBY', KEYS[3], -contribution, ARGV[1]))
+if creatorScore < floor then
+	redis.call('ZADD', KEYS[3], floor, ARGV[1])
+end
+redis.call('HSET', KEYS[4], 'score', tostring(score))
+return {tostring(contribution), tostring(score)}
+`)
+
+// UserScore is a user on a user board
+type UserScore struct {
+	UserID string  `json:"user_id"`
+	Rank   int64   `json:"rank"`
+	Score  float64 `json:"score"`
+}
+
+// recordContribution indexes the score a user gave a video of a creator and adds it to the
+// user boards, negative feedback does not make a user less engaged
+func recordContribution(ctx context.Context, rdb *redis.Client, interaction Interaction, creatorID string, increment float64) error {
+	pipe := rdb.Pipeline()
+	pipe.HIncrByFloat(ctx, userContributionsKey(interaction.UserID), interaction.VideoID, increment)
+	pipe.Incr(ctx, userContributionCountKey(interaction.UserID))
+	if increment > 0 {
+		pipe.ZIncrBy(ctx, usersRankingKey, increment, interaction.UserID)
+		pipe.ZIncrBy(ctx, creatorFansKey(creatorID), increment, interaction.UserID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// removeContributor takes a user off the global user board and the fan boards of creators
+func removeContributor(ctx context.Context, rdb *redis.Client, userID string, creatorIDs []string) error {
+	pipe := rdb.Pipeline()
+	pipe.ZRem(ctx, usersRankingKey, userID)
+	for _, creatorID := range creatorIDs {
+		pipe.ZRem(ctx, creatorFansKey(creatorID), userID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// parsePage reads the limit and offset of a paginated request
+func parsePage(r *http.Request) (limit, offset int, err error) {
+	limit, err = strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10 // default limit
+	}
+	if limit > 100 || limit < 1 {
+		return 0, 0, ErrorLimitRange
+	}
+	offset, err = strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0 // default offset
+	}
+	if offset < 0 {
+		return 0, 0, ErrorOffsetRange
+	}
+	return limit, offset, nil
+}
+
+// userBoard reads limit users of a user board from offset
+func userBoard(ctx context.Context, rdb *redis.Client, key string, offset, limit int) ([]UserScore, error) {
+	entries, err := rdb.ZRevRangeWithScores(ctx, key, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	users := make([]UserScore, len(entries))
+	for i, entry := range entries {
+		users[i] = UserScore{
+			UserID: entry.Member.(string),
+			Rank:   int64(offset + i + 1),
+			Score:  entry.Score,
+		}
+	}
+	return users, nil
+}
+
+// GetUserRanking returns the most active users
+//
+//	@Summary		Get user rankings
+//	@Description	Retrieve the users ranked by the score their interactions contributed to all videos. Negative feedback is not counted and confirmed bots are left out.
+//	@Tags			Ranking
+//	@Produce		json
+//	@Param			limit	query		int	false	"Number of users to retrieve (default: 10)"
+//	@Param			offset	query		int	false	"Offset for pagination (default: 0)"
+//	@Success		200		{object}	httputil.HttpResponse{data=[]handler.UserScore}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/users/ranking [get]
+func (h *UserHandler) GetUserRanking(w http.ResponseWriter, r *http.Request) error {
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	users, err := userBoard(r.Context(), h.redis, usersRankingKey, offset, limit)
+	if err != nil {
+		h.logger.Error("failed to get user rankings", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: users,
+	})
+}
+
+// GetFans returns the top fans of a creator
+//
+//	@Summary		Get creator fans
+//	@Description	Retrieve the users ranked by the score their interactions contributed to the videos of a creator. Negative feedback is not counted and confirmed bots are left out.
+//	@Tags			Creator
+//	@Produce		json
+//	@Param			id		path		string	true	"Creator ID"
+//	@Param			limit	query		int		false	"Number of users to retrieve (default: 10)"
+//	@Param			offset	query		int		false	"Offset for pagination (default: 0)"
+//	@Success		200		{object}	httputil.HttpResponse{data=[]handler.UserScore}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/creators/{id}/fans [get]
+func (h *CreatorHandler) GetFans(w http.ResponseWriter, r *http.Request) error {
+	creatorID := r.PathValue("id")
+	limit, offset, err := parsePage(r)
+	if err != nil {
+		return err
+	}
+	fans, err := userBoard(r.Context(), h.redis, creatorFansKey(creatorID), offset, limit)
+	if err != nil {
+		h.logger.Error("failed to get creator fans", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: fans,
+	})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserBoards(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	users := &UserHandler{redis: handler.redis, logger: logger, personal: handler.personal}
+	creators := &CreatorHandler{redis: handler.redis, logger: logger}
+	admin := &AdminHandler{redis: handler.redis, logger: logger, audit: NewAuditLog(handler.redis), events: handler.events}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "0")
+
+	interactions := []Interaction{
+		{VideoID: "video1", Type: InteractionLike, UserID: "user1"},
+		{VideoID: "video1", Type: InteractionView, UserID: "user1"},
+		{VideoID: "video1", Type: InteractionView, UserID: "user2"},
+		{VideoID: "video2", Type: InteractionShare, UserID: "user2"},
+		{VideoID: "video2", Type: InteractionDislike, UserID: "user2"},
+		{VideoID: "video2", Type: InteractionView, UserID: "bot1"},
+		{VideoID: "video2", Type: InteractionComment, UserID: "bot1"},
+	}
+	for _, interaction := range interactions {
+		interaction.Timestamp = 1690000000
+		body, _ := json.Marshal(interaction)
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, handler.UpdateScore(httptest.NewRecorder(), asService(req)))
+	}
+
+	board := func(t *testing.T, get func(http.ResponseWriter, *http.Request) error, path, creatorID string) []UserScore {
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", creatorID)
+		rr := httptest.NewRecorder()
+		require.NoError(t, get(rr, req))
+		var response struct {
+			Data []UserScore `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data
+	}
+
+	t.Run("user ranking", func(t *testing.T) {
+		assert.Equal(t, []UserScore{
+			{UserID: "user2", Rank: 1, Score: 21},
+			{UserID: "bot1", Rank: 2, Score: 11},
+			{UserID: "user1", Rank: 3, Score: 6},
+		}, board(t, users.GetUserRanking, "/api/v1/users/ranking", ""))
+		assert.Equal(t, []UserScore{
+			{UserID: "bot1", Rank: 2, Score: 11},
+		}, board(t, users.GetUserRanking, "/api/v1/users/ranking?limit=1&offset=1", ""))
+	})
+
+	t.Run("creator fans", func(t *testing.T) {
+		assert.Equal(t, []UserScore{
+			{UserID: "user1", Rank: 1, Score: 6},
+			{UserID: "user2", Rank: 2, Score: 1},
+		}, board(t, creators.GetFans, "/api/v1/creators/creator1/fans", "creator1"))
+		assert.Equal(t, []UserScore{}, board(t, creators.GetFans, "/api/v1/creators/creator3/fans", "creator3"))
+	})
+
+	t.Run("invalid page", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/users/ranking?limit=101", nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, users.GetUserRanking(httptest.NewRecorder(), req), ErrorLimitRange)
+		req, err = http.NewRequest("GET", "/api/v1/creators/creator1/fans?offset=-1", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "creator1")
+		assert.ErrorIs(t, creators.GetFans(httptest.NewRecorder(), req), ErrorOffsetRange)
+	})
+
+	t.Run("confirmed bots are removed", func(t *testing.T) {
+		confirm := func() error {
+			req, err := http.NewRequest("POST", "/api/v1/admin/users/bot1/bot", nil)
+			require.NoError(t, err)
+			req.SetPathValue("id", "bot1")
+			return admin.ConfirmBot(httptest.NewRecorder(), asAdmin(req, "alice"))
+		}
+		// an earlier confirmation marked the bot and failed before removing its scores
+		mr.SAdd("fraud:bots", "bot1")
+		require.NoError(t, confirm())
+		assert.Equal(t, "15", mr.HGet("video:video2", "score"))
+		assert.ErrorIs(t, confirm(), ErrorAlreadyBot)
+
+		assert.Len(t, board(t, users.GetUserRanking, "/api/v1/users/ranking", ""), 2)
+		assert.Equal(t, []UserScore{
+			{UserID: "user2", Rank: 1, Score: 20},
+		}, board(t, creators.GetFans, "/api/v1/creators/creator2/fans", "creator2"))
+	})
+}
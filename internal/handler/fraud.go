@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/internal/fraud"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// BotConfirmation summarises the retroactive removal of a bot's interactions
+type BotConfirmation struct {
+	UserID       string `json:"user_id"`
+	Interactions int    `json:"interactions"`
+	// Adjustments is the score change applied to each video the bot interacted with
+	Adjustments map[string]float64 `json:"adjustments"`
+}
+
+// addScore adds an increment to the global and creator rankings and the video hash,
+// never bringing the score below ScoreFloor, and returns the new score
+func addScore(ctx context.Context, rdb *redis.Client, videoID, creatorID string, increment float64) (float64, error) {
+	newScore, err := incrByWithFloor.Run(ctx, rdb, []string{"rankings:global"}, increment, videoID, ScoreFloor).Float64()
+	if err != nil {
+		return 0, err
+	}
+	creatorKey := fmt.Sprintf("creator:%s:videos", creatorID)
+	if err := incrByWithFloor.Run(ctx, rdb, []string{creatorKey}, increment, videoID, ScoreFloor).Err(); err != nil {
+		return 0, err
+	}
+	return newScore, rdb.HSet(ctx, fmt.Sprintf("video:%s", videoID), "score", newScore).Err()
+}
+
+// ListFlags returns the interactions held or dropped by fraud detection
+//
+//	@Summary		List flagged interactions
+//	@Description	List the interactions delayed or dropped by fraud detection newest first, with the rules and signals behind each decision
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			pending	query		bool	false	"Only the delayed interactions awaiting review"
+//	@Param			limit	query		int		false	"Number of flags to retrieve (default: 50)"
+//	@Success		200		{object}	httputil.HttpResponse{data=[]fraud.Flag}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		401		{object}	httputil.ErrorResponse
+//	@Failure		403		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/fraud/flags [get]
+func (h *AdminHandler) ListFlags(w http.ResponseWriter, r *http.Request) error {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 50
+	}
+	if limit > 1000 || limit < 1 {
+		return ErrorLimitRange
+	}
+	pending, _ := strconv.ParseBool(r.URL.Query().Get("pending"))
+
+	flags, err := fraud.Flags(r.Context(), h.redis, int64(limit), pending)
+	if err != nil {
+		h.logger.Error("failed to get flagged interactions", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: flags,
+	})
+}
+
+// ReleaseFlag scores a delayed interaction
+//
+//	@Summary		Release a delayed interaction
+//	@Description	Score an interaction delayed by fraud detection with the weight of its decision and the trust of the user when it was delayed.
+//	@Description	It is scored like an accepted interaction, including its negative feedback and the user's seen history.
+//	@Description	Interactions of users confirmed as bots cannot be released, as rebuilds leave them out.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Flag ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=fraud.Flag}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		409	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/fraud/flags/{id}/release [post]
+func (h *AdminHandler) ReleaseFlag(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	flag, err := h.pendingFlag(ctx, r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+
+	var flagged flaggedInteraction
+	if err := json.Unmarshal(flag.Interaction, &flagged); err != nil {
+		h.logger.Error("failed to decode flagged interaction", zap.String("id", flag.ID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	interaction := flagged.interaction()
+	if weight := flag.Decision.ScoreWeight(); weight != 1 {
+		interaction.Weight = weight
+	}
+	// confirming a bot drops their interactions from the boards and from rebuilds
+	bot, err := fraud.IsBot(ctx, h.redis, interaction.UserID)
+	if err != nil {
+		h.logger.Error("failed to get bots", zap.String("user_id", interaction.UserID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if bot {
+		return ErrorReleaseBot
+	}
+	creatorID, err := h.redis.HGet(ctx, fmt.Sprintf("video:%s", interaction.VideoID), "creator_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrorVideoNotFound
+	}
+	if err != nil {
+		h.logger.Error("failed to get video data", zap.String("video_id", interaction.VideoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+
+	before, err := h.redis.ZScore(ctx, "rankings:global", interaction.VideoID).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.logger.Error("failed to get video score", zap.String("video_id", interaction.VideoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if err := h.claimFlag(ctx, flag.ID); err != nil {
+		return err
+	}
+	after, err := h.ranking.apply(ctx, interaction, creatorID)
+	if err != nil {
+		h.unclaimFlag(ctx, flag.ID)
+		return err
+	}
+	// the flag stays pending until the interaction is logged and scored
+	if err := h.resolveFlag(ctx, flag.ID); err != nil {
+		return err
+	}
+
+	if _, err := h.record(r, ActionReleaseInteraction, AuditTargetVideo, interaction.VideoID, flag.ID,
+		map[string]float64{"score": before}, map[string]float64{"score": after}); err != nil {
+		return err
+	}
+	flag.Pending = false
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: flag,
+	})
+}
+
+// RejectFlag discards a delayed interaction
+//
+//	@Summary		Reject a delayed interaction
+//	@Description	Discard an interaction delayed by fraud detection, it is never scored and lowers the trust of the user
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Flag ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=fraud.Flag}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/fraud/flags/{id} [delete]
+func (h *AdminHandler) RejectFlag(w http.ResponseWriter, r *http.Request) error {
+	flag, err := h.pendingFlag(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	if err := h.claimFlag(r.Context(), flag.ID); err != nil {
+		return err
+	}
+	if err := h.resolveFlag(r.Context(), flag.ID); err != nil {
+		return err
+	}
+	if _, err := h.record(r, ActionRejectInteraction, AuditTargetVideo, flag.VideoID, flag.ID,
+		map[string]bool{"pending": true}, map[string]bool{"pending": false}); err != nil {
+		return err
+	}
+	if err := penalizeTrust(r.Context(), h.redis, h.audit, auditActor(r), flag.UserID, flag.ID, h.trustPenalty); err != nil {
+		h.logger.Error("failed to lower user trust", zap.String("user_id", flag.UserID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	flag.Pending = false
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: flag,
+	})
+}
+
+// pendingFlag returns a flagged interaction awaiting review
+func (h *AdminHandler) pendingFlag(ctx context.Context, id string) (fraud.Flag, error) {
+	flag, ok, err := fraud.GetFlag(ctx, h.redis, id)
+	if err != nil {
+		h.logger.Error("failed to get flagged interaction", zap.String("id", id), zap.Error(err))
+		return flag, ErrorGetDataFailed
+	}
+	if !ok || !flag.Pending {
+		return flag, ErrorFlagNotPending
+	}
+	return flag, nil
+}
+
+// flagClaimTTL bounds how long a review holds a flag when it fails without unclaiming it
+const flagClaimTTL = time.Minute
+
+// claimFlag reserves a pending flag for this review, once when reviews race
+func (h *AdminHandler) claimFlag(ctx context.Context, id string) error {
+	claimed, err := fraud.Claim(ctx, h.redis, id, flagClaimTTL)
+	if err != nil {
+		h.logger.Error("failed to claim flagged interaction", zap.String("id", id), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if !claimed {
+		return ErrorFlagNotPending
+	}
+	return nil
+}
+
+// unclaimFlag lets a failed review of a flag be retried
+func (h *AdminHandler) unclaimFlag(ctx context.Context, id string) {
+	if err := fraud.Unclaim(ctx, h.redis, id); err != nil {
+		h.logger.Error("failed to unclaim flagged interaction", zap.String("id", id), zap.Error(err))
+	}
+}
+
+// resolveFlag ends the review of a pending flag
+func (h *AdminHandler) resolveFlag(ctx context.Context, id string) error {
+	resolved, err := fraud.Resolve(ctx, h.redis, id)
+	if err != nil {
+		h.logger.Error("failed to resolve flagged interaction", zap.String("id", id), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if !resolved {
+		return ErrorFlagNotPending
+	}
+	return nil
+}
+
+// ConfirmBot marks a user as a bot and removes their past interactions from the scores
+//
+//	@Summary		Confirm a user as a bot
+//	@Description	Drop every later interaction of the user and retroactively subtract the scores of the logged ones.
+//	@Description	Rebuilds leave out the interactions of confirmed bots.
+//	@Description	Confirmed bots are removed from the user rankings and the fan rankings of creators.
+//	@Description	The scores come from an index of what each user contributed to each video, a failed confirmation is finished by retrying it.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.BotConfirmation}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		409	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/users/{id}/bot [post]
+func (h *AdminHandler) ConfirmBot(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	userID := r.PathValue("id")
+	// later interactions are dropped from now on, a failed confirmation is finished by retrying it
+	added, err := fraud.ConfirmBot(ctx, h.redis, userID)
+	if err != nil {
+		h.logger.Error("failed to confirm bot", zap.String("user_id", userID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+
+	contributions, err := h.redis.HGetAll(ctx, userContributionsKey(userID)).Result()
+	if err != nil {
+		h.logger.Error("failed to get bot contributions", zap.String("user_id", userID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	count, err := h.redis.Get(ctx, userContributionCountKey(userID)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.logger.Error("failed to get bot contributions", zap.String("user_id", userID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if !added && len(contributions) == 0 && count == 0 {
+		return ErrorAlreadyBot
+	}
+
+	confirmation := BotConfirmation{UserID: userID, Interactions: count, Adjustments: make(map[string]float64)}
+	creatorIDs := make(map[string]string, len(contributions))
+	for videoID := range contributions {
+		creatorID, err := h.redis.HGet(ctx, fmt.Sprintf("video:%s", videoID), "creator_id").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			h.logger.Error("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		creatorIDs[videoID] = creatorID
+	}
+	creators := make([]string, 0, len(creatorIDs))
+	for _, creatorID := range creatorIDs {
+		if creatorID != "" {
+			creators = append(creators, creatorID)
+		}
+	}
+	if err := removeContributor(ctx, h.redis, userID, creators); err != nil {
+		h.logger.Error("failed to remove bot from user rankings", zap.String("user_id", userID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+
+	for videoID, creatorID := range creatorIDs {
+		if creatorID == "" {
+			// the video is gone, there is no score to correct
+			if err := h.redis.HDel(ctx, userContributionsKey(userID), videoID).Err(); err != nil {
+				h.logger.Error("failed to remove bot contribution", zap.String("video_id", videoID), zap.Error(err))
+				return ErrorUpdateDataFailed
+			}
+			continue
+		}
+		keys := []string{userContributionsKey(userID), "rankings:global", fmt.Sprintf("creator:%s:videos", creatorID), fmt.Sprintf("video:%s", videoID)}
+		values, err := removeContribution.Run(ctx, h.redis, keys, videoID, ScoreFloor).StringSlice()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			h.logger.Error("failed to remove bot interactions", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorUpdateDataFailed
+		}
+		if contribution, _ := strconv.ParseFloat(values[0], 64); contribution != 0 {
+			confirmation.Adjustments[videoID] = -contribution
+		}
+	}
+	if err := h.redis.Del(ctx, userContributionCountKey(userID)).Err(); err != nil {
+		h.logger.Error("failed to remove bot contributions", zap.String("user_id", userID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+
+	h.logger.Warn("bot confirmed",
+		zap.String("user_id", userID),
+		zap.Int("interactions", confirmation.Interactions),
+		zap.Int("videos", len(confirmation.Adjustments)),
+	)
+	if _, err := h.record(r, ActionConfirmBot, AuditTargetUser, userID, "",
+		map[string]bool{"bot": false}, confirmation); err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: confirmation,
+	})
+}
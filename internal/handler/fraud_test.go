@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/internal/fraud"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraudDetection(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	rules := fraud.DefaultRules(fraud.Config{
+		PairVelocityMax:  1,
+		NewAccountAge:    24 * time.Hour,
+		NewAccountWeight: 0.5,
+	})
+	rules = append(rules, fraud.Rule{
+		Name:   "review_shares",
+		Types:  []string{InteractionShare},
+		Match:  func(fraud.Signals) bool { return true },
+		Action: fraud.ActionDelay,
+	})
+	handler.fraud = fraud.NewDetector(handler.redis, 10*time.Minute, rules)
+	handler.audit = NewAuditLog(handler.redis)
+	handler.trustPenalty = 0.25
+	admin := &AdminHandler{redis: handler.redis, logger: logger, audit: handler.audit, events: handler.events, trustPenalty: 0.25, ranking: handler}
+
+	ctx := context.Background()
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "0")
+	mr.HSet("user:user1", "created_at", "1680000000")
+	for _, userID := range []string{"user2", "user3", "user4"} {
+		mr.HSet("user:"+userID, "created_at", strconv.FormatInt(time.Now().Unix(), 10))
+	}
+
+	interact := func(t *testing.T, userID, videoID, interactionType string) int {
+		body, _ := json.Marshal(Interaction{VideoID: videoID, Type: interactionType, UserID: userID, Timestamp: 1690000000})
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.UpdateScore(rr, asService(req)))
+		return rr.Code
+	}
+	score := func(t *testing.T, videoID string) float64 {
+		score, err := mr.ZScore("rankings:global", videoID)
+		require.NoError(t, err)
+		return score
+	}
+	review := func(t *testing.T, method, path, id string, endpoint func(http.ResponseWriter, *http.Request) error) (fraud.Flag, error) {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		if err := endpoint(rr, asAdmin(req, "alice")); err != nil {
+			return fraud.Flag{}, err
+		}
+		var response struct {
+			Data fraud.Flag `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data, nil
+	}
+	trust := func(t *testing.T, userID string) float64 {
+		trust, err := fraud.Trust(ctx, handler.redis, userID)
+		require.NoError(t, err)
+		return trust
+	}
+	pendingFlag := func(t *testing.T, userID string) fraud.Flag {
+		flags, err := fraud.Flags(ctx, handler.redis, 10, true)
+		require.NoError(t, err)
+		for _, flag := range flags {
+			if flag.UserID == userID {
+				return flag
+			}
+		}
+		t.Fatalf("no pending flag for %s", userID)
+		return fraud.Flag{}
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, interact(t, "user1", "video1", InteractionLike))
+		assert.Equal(t, 5.0, score(t, "video1"))
+	})
+
+	t.Run("new account discount", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, interact(t, "user2", "video1", InteractionLike))
+		assert.Equal(t, 7.5, score(t, "video1"))
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, interact(t, "user1", "video1", InteractionLike))
+		assert.Equal(t, 7.5, score(t, "video1"))
+
+		flags, err := fraud.Flags(ctx, handler.redis, 10, false)
+		require.NoError(t, err)
+		require.Len(t, flags, 1)
+		assert.Equal(t, fraud.ActionDrop, flags[0].Decision.Action)
+		assert.Equal(t, []string{"pair_velocity"}, flags[0].Decision.Rules)
+		assert.False(t, flags[0].Pending)
+
+		assert.Equal(t, 0.75, trust(t, "user1"))
+		entries, err := handler.audit.Query(ctx, AuditFilter{TargetType: AuditTargetUser, TargetID: "user1", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, FraudActor, entries[0].Actor)
+		assert.Equal(t, ActionPenalizeTrust, entries[0].Action)
+		assert.Equal(t, flags[0].ID, entries[0].Reason)
+		assert.JSONEq(t, `{"trust":0.75}`, string(entries[0].After))
+
+		_, err = review(t, "DELETE", "/api/v1/admin/fraud/flags/"+flags[0].ID, flags[0].ID, admin.RejectFlag)
+		assert.ErrorIs(t, err, ErrorFlagNotPending)
+	})
+
+	t.Run("delayed and released", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, interact(t, "user1", "video2", InteractionShare))
+		assert.Equal(t, "0", mr.HGet("video:video2", "score"))
+
+		flag := pendingFlag(t, "user1")
+		assert.Equal(t, 0.75, flag.Decision.Trust)
+		released, err := review(t, "POST", "/api/v1/admin/fraud/flags/"+flag.ID+"/release", flag.ID, admin.ReleaseFlag)
+		require.NoError(t, err)
+		assert.False(t, released.Pending)
+		assert.Equal(t, 15.0, score(t, "video2"))
+		assert.Equal(t, "15", mr.HGet("video:video2", "score"))
+		assert.Equal(t, 0.75, trust(t, "user1"))
+		// released interactions are scored like accepted ones
+		seen, err := handler.redis.ZScore(ctx, "user:user1:seen", "video2").Result()
+		require.NoError(t, err)
+		assert.Positive(t, seen)
+		rising, err := handler.rising.Top(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Contains(t, rising, redis.Z{Score: 15, Member: "video2"})
+
+		entries, err := admin.audit.Query(ctx, AuditFilter{TargetType: AuditTargetVideo, TargetID: "video2", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, ActionReleaseInteraction, entries[0].Action)
+		assert.Equal(t, flag.ID, entries[0].Reason)
+
+		_, err = review(t, "POST", "/api/v1/admin/fraud/flags/"+flag.ID+"/release", flag.ID, admin.ReleaseFlag)
+		assert.ErrorIs(t, err, ErrorFlagNotPending)
+	})
+
+	t.Run("delayed and rejected", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, interact(t, "user3", "video2", InteractionShare))
+		flag := pendingFlag(t, "user3")
+		assert.Equal(t, 0.5, flag.Decision.Weight)
+
+		rejected, err := review(t, "DELETE", "/api/v1/admin/fraud/flags/"+flag.ID, flag.ID, admin.RejectFlag)
+		require.NoError(t, err)
+		assert.False(t, rejected.Pending)
+		assert.Equal(t, 15.0, score(t, "video2"))
+		assert.Equal(t, 0.75, trust(t, "user3"))
+	})
+
+	t.Run("confirm bot", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/v1/admin/users/user1/bot", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "user1")
+		rr := httptest.NewRecorder()
+		require.NoError(t, admin.ConfirmBot(rr, asAdmin(req, "alice")))
+
+		var response struct {
+			Data BotConfirmation `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, 2, response.Data.Interactions)
+		assert.Equal(t, map[string]float64{"video1": -5, "video2": -15}, response.Data.Adjustments)
+		assert.Equal(t, 2.5, score(t, "video1"))
+		assert.Equal(t, 0.0, score(t, "video2"))
+		assert.Equal(t, fmt.Sprint(2.5), mr.HGet("video:video1", "score"))
+
+		err = admin.ConfirmBot(httptest.NewRecorder(), asAdmin(req, "alice"))
+		assert.ErrorIs(t, err, ErrorAlreadyBot)
+
+		assert.Equal(t, http.StatusAccepted, interact(t, "user1", "video2", InteractionView))
+		assert.Equal(t, 0.0, score(t, "video2"))
+	})
+
+	t.Run("rebuild excludes bots", func(t *testing.T) {
+		result, err := RebuildRankings(ctx, handler.redis, handler.events, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Excluded)
+		assert.Equal(t, 1, result.Interactions)
+		assert.Equal(t, 2.5, score(t, "video1"))
+		assert.Equal(t, "0", mr.HGet("video:video2", "score"))
+	})
+
+	t.Run("bots are not released", func(t *testing.T) {
+		assert.Equal(t, http.StatusAccepted, interact(t, "user5", "video1", InteractionShare))
+		flag := pendingFlag(t, "user5")
+		_, err := fraud.ConfirmBot(ctx, handler.redis, "user5")
+		require.NoError(t, err)
+
+		_, err = review(t, "POST", "/api/v1/admin/fraud/flags/"+flag.ID+"/release", flag.ID, admin.ReleaseFlag)
+		assert.ErrorIs(t, err, ErrorReleaseBot)
+		assert.Equal(t, 2.5, score(t, "video1"))
+		assert.True(t, pendingFlag(t, "user5").Pending)
+	})
+
+	t.Run("trust weighting", func(t *testing.T) {
+		mr.HSet("user:user4", "created_at", "1680000000")
+		setTrust := func(update TrustUpdate) (*httptest.ResponseRecorder, error) {
+			body, _ := json.Marshal(update)
+			req, err := http.NewRequest("PUT", "/api/v1/admin/users/user4/trust", bytes.NewReader(body))
+			require.NoError(t, err)
+			req.SetPathValue("id", "user4")
+			rr := httptest.NewRecorder()
+			return rr, admin.SetTrust(rr, asAdmin(req, "alice"))
+		}
+
+		_, err := setTrust(TrustUpdate{Trust: 3, Reason: "verified reviewer"})
+		assert.ErrorIs(t, err, ErrorInvalidTrust)
+		_, err = setTrust(TrustUpdate{Trust: 1.5})
+		assert.ErrorIs(t, err, ErrorInvalidTrust)
+
+		rr, err := setTrust(TrustUpdate{Trust: 1.5, Reason: "verified reviewer"})
+		require.NoError(t, err)
+		var response struct {
+			Data AuditEntry `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, ActionSetTrust, response.Data.Action)
+		assert.JSONEq(t, `{"trust":1}`, string(response.Data.Before))
+		assert.JSONEq(t, `{"trust":1.5}`, string(response.Data.After))
+
+		req, err := http.NewRequest("GET", "/api/v1/admin/users/user4/trust", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "user4")
+		rr = httptest.NewRecorder()
+		require.NoError(t, admin.GetTrust(rr, asAdmin(req, "alice")))
+		var trustResponse struct {
+			Data UserTrust `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&trustResponse))
+		assert.Equal(t, UserTrust{UserID: "user4", Trust: 1.5}, trustResponse.Data)
+
+		assert.Equal(t, http.StatusOK, interact(t, "user4", "video1", InteractionLike))
+		assert.Equal(t, 10.0, score(t, "video1"))
+	})
+}
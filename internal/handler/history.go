@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"realtime_ranking/internal/moderation"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// BoardGlobal is the board backed by rankings:global. Creator boards are named "creator:<id>".
+const BoardGlobal = "global"
+
+// BoardRising is the board of the RisingBoard, it has no history
+const BoardRising = "rising"
+
+// boardKey returns the sorted set holding a board
+func boardKey(board string) string {
+	if creatorID, ok := strings.CutPrefix(board, "creator:"); ok {
+		return fmt.Sprintf("creator:%s:videos", creatorID)
+	}
+	return "rankings:global"
+}
+
+func historyKey(board string, at int64) string {
+	return fmt.Sprintf("history:%s:%d", board, at)
+}
+
+// historyTruncatedKey is set when the snapshot at a slot left out part of the board
+func historyTruncatedKey(board string, at int64) string {
+	return fmt.Sprintf("history:%s:%d:truncated", board, at)
+}
+
+func historyIndexKey(board string) string {
+	return fmt.Sprintf("history:%s:index", board)
+}
+
+// Snapshotter periodically stores the top N of every board so ranks can be looked up over time
+type Snapshotter struct {
+	redis     *redis.Client
+	logger    *zap.Logger
+	interval  time.Duration
+	topN      int64
+	retention time.Duration
+}
+
+func NewSnapshotter(redis *redis.Client, logger *zap.Logger, interval time.Duration, topN int64, retention time.Duration) *Snapshotter {
+	return &Snapshotter{
+		redis:     redis,
+		logger:    logger,
+		interval:  interval,
+		topN:      topN,
+		retention: retention,
+	}
+}
+
+// Run takes a snapshot every interval until ctx is cancelled
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.Snapshot(ctx, now); err != nil {
+				s.logger.Error("failed to snapshot rankings", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Snapshot stores the boards as of at, truncated to the interval. Only one instance
+// takes the snapshot for a given slot.
+func (s *Snapshotter) Snapshot(ctx context.Context, at time.Time) error {
+	slot := at.Truncate(s.interval).Unix()
+	acquired, err := s.redis.SetNX(ctx, fmt.Sprintf("history:lock:%d", slot), 1, s.interval).Result()
+	if err != nil {
+		return fmt.Errorf("acquire snapshot lock: %w", err)
+	}
+	if !acquired {
+		return nil
+	}
+
+	boards := []string{BoardGlobal}
+	creatorKeys, err := scanKeys(ctx, s.redis, "creator:*:videos")
+	if err != nil {
+		return fmt.Errorf("scan creator rankings: %w", err)
+	}
+	for _, key := range creatorKeys {
+		boards = append(boards, "creator:"+strings.TrimSuffix(strings.TrimPrefix(key, "creator:"), ":videos"))
+	}
+
+	for _, board := range boards {
+		if err := s.snapshotBoard(ctx, board, slot); err != nil {
+			return fmt.Errorf("snapshot %s: %w", board, err)
+		}
+	}
+	return nil
+}
+
+// snapshotBoard leaves out the hidden videos so snapshot ranks match the served boards.
+// It reads one entry past the top N to record whether the snapshot is truncated.
+func (s *Snapshotter) snapshotBoard(ctx context.Context, board string, slot int64) error {
+	entries, err := moderation.Range(ctx, s.redis, boardKey(board), nil, 0, s.topN+1)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	truncated := int64(len(entries)) > s.topN
+	if truncated {
+		entries = entries[:s.topN]
+	}
+
+	key := historyKey(board, slot)
+	truncatedKey := historyTruncatedKey(board, slot)
+	indexKey := historyIndexKey(board)
+	expired := strconv.FormatInt(slot-int64(s.retention.Seconds()), 10)
+	_, err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key, truncatedKey)
+		pipe.ZAdd(ctx, key, entries...)
+		pipe.Expire(ctx, key, s.retention)
+		if truncated {
+			pipe.Set(ctx, truncatedKey, 1, s.retention)
+		}
+		pipe.ZAdd(ctx, indexKey, redis.Z{Score: float64(slot), Member: slot})
+		pipe.ZRemRangeByScore(ctx, indexKey, "-inf", "("+expired)
+		pipe.Expire(ctx, indexKey, s.retention)
+		return nil
+	})
+	return err
+}
+
+// HistoryPoint is a video's position in one snapshot. Rank and Score are nil when
+// the video was outside the stored top N.
+type HistoryPoint struct {
+	Timestamp int64    `json:"timestamp"`
+	Rank      *int64   `json:"rank"`
+	Score     *float64 `json:"score"`
+}
+
+// snapshotTimes returns the snapshot slots of a board within [from, to]
+func snapshotTimes(ctx context.Context, rdb *redis.Client, board string, from, to int64) ([]int64, error) {
+	members, err := rdb.ZRangeByScore(ctx, historyIndexKey(board), &redis.ZRangeBy{
+		Min: strconv.FormatInt(from, 10),
+		Max: strconv.FormatInt(to, 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	slots := make([]int64, 0, len(members))
+	for _, member := range members {
+		slot, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// videoHistory returns the 1-based rank and score of a video in each snapshot of a board within [from, to]
+func videoHistory(ctx context.Context, rdb *redis.Client, board, videoID string, from, to int64) ([]HistoryPoint, error) {
+	slots, err := snapshotTimes(ctx, rdb, board, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := rdb.Pipeline()
+	rankCmds := make([]*redis.IntCmd, len(slots))
+	scoreCmds := make([]*redis.FloatCmd, len(slots))
+	for i, slot := range slots {
+		rankCmds[i] = pipe.ZRevRank(ctx, historyKey(board, slot), videoID)
+		scoreCmds[i] = pipe.ZScore(ctx, historyKey(board, slot), videoID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	points := make([]HistoryPoint, 0, len(slots))
+	for i, slot := range slots {
+		point := HistoryPoint{Timestamp: slot}
+		if rank, err := rankCmds[i].Result(); err == nil {
+			rank++
+			point.Rank = &rank
+		}
+		if score, err := scoreCmds[i].Result(); err == nil {
+			point.Score = &score
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// boardAt returns the entries of the latest snapshot of a board taken at or before at,
+// leaving out the videos hidden since
+func boardAt(ctx context.Context, rdb *redis.Client, board string, at int64, limit int64) (int64, []redis.Z, error) {
+	members, err := rdb.ZRevRangeByScore(ctx, historyIndexKey(board), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(at, 10),
+		Count: 1,
+	}).Result()
+	if err != nil || len(members) == 0 {
+		return 0, nil, err
+	}
+	slot, err := strconv.ParseInt(members[0], 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	entries, err := moderation.Range(ctx, rdb, historyKey(board, slot), nil, 0, limit)
+	return slot, entries, err
+}
+
+// previousSnapshot holds the 1-based ranks of videos in the latest snapshot of a board
+type previousSnapshot struct {
+	ranks map[string]int64
+	// truncated is true when the snapshot stopped at its top N, so a video missing from
+	// it may have been ranked below
+	truncated bool
+}
+
+// previousRanks returns the ranks of videos in the latest snapshot of a board, nil when
+// the board has no snapshot yet
+func previousRanks(ctx context.Context, rdb *redis.Client, board string, videoIDs []string) (*previousSnapshot, error) {
+	slots, err := rdb.ZRevRange(ctx, historyIndexKey(board), 0, 0).Result()
+	if err != nil || len(slots) == 0 {
+		return nil, err
+	}
+	slot, err := strconv.ParseInt(slots[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	key := historyKey(board, slot)
+	pipe := rdb.Pipeline()
+	truncatedCmd := pipe.Exists(ctx, historyTruncatedKey(board, slot))
+	rankCmds := make([]*redis.IntCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		rankCmds[i] = pipe.ZRevRank(ctx, key, videoID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	previous := &previousSnapshot{
+		ranks:     make(map[string]int64, len(videoIDs)),
+		truncated: truncatedCmd.Val() == 1,
+	}
+	for i, videoID := range videoIDs {
+		if rank, err := rankCmds[i].Result(); err == nil {
+			previous.ranks[videoID] = rank + 1
+		}
+	}
+	return previous, nil
+}
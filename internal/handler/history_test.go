@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/internal/moderation"
+	"realtime_ranking/pkg/httputil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	snapshotter := NewSnapshotter(handler.redis, logger, time.Minute, 2, time.Hour)
+	videoHandler := &VideoHandler{redis: handler.redis, logger: logger}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "100")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "50")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "10")
+	mr.ZAdd("rankings:global", 100, "video1")
+	mr.ZAdd("rankings:global", 50, "video2")
+	mr.ZAdd("rankings:global", 10, "video3")
+	mr.ZAdd("creator:creator1:videos", 100, "video1")
+	mr.ZAdd("creator:creator1:videos", 50, "video2")
+	mr.ZAdd("creator:creator2:videos", 10, "video3")
+
+	first := time.Unix(1690000000, 0)
+	second := first.Add(time.Minute)
+	require.NoError(t, snapshotter.Snapshot(ctx, first))
+
+	mr.ZAdd("rankings:global", 200, "video3")
+	mr.ZAdd("creator:creator2:videos", 200, "video3")
+	require.NoError(t, snapshotter.Snapshot(ctx, second))
+	// another instance in the same slot does nothing
+	mr.ZAdd("rankings:global", 300, "video2")
+	require.NoError(t, snapshotter.Snapshot(ctx, second.Add(time.Second)))
+
+	t.Run("video history", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/video3/history?from=1689990000&to=1690001000", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video3")
+		rr := httptest.NewRecorder()
+
+		err = videoHandler.GetHistory(rr, req)
+		require.NoError(t, err)
+
+		var response struct {
+			Data VideoHistory `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, BoardGlobal, response.Data.Board)
+		require.Len(t, response.Data.Points, 2)
+
+		// outside the top 2 in the first snapshot
+		assert.Equal(t, first.Truncate(time.Minute).Unix(), response.Data.Points[0].Timestamp)
+		assert.Nil(t, response.Data.Points[0].Rank)
+		assert.Equal(t, int64(1), *response.Data.Points[1].Rank)
+		assert.Equal(t, 200.0, *response.Data.Points[1].Score)
+	})
+
+	t.Run("creator board", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/video2/history?board=creator&from=1689990000&to=1690001000", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video2")
+		rr := httptest.NewRecorder()
+
+		err = videoHandler.GetHistory(rr, req)
+		require.NoError(t, err)
+
+		var response struct {
+			Data VideoHistory `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, "creator:creator1", response.Data.Board)
+		require.Len(t, response.Data.Points, 2)
+		assert.Equal(t, int64(2), *response.Data.Points[0].Rank)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/video1/history?from=10&to=1", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video1")
+		rr := httptest.NewRecorder()
+
+		err = videoHandler.GetHistory(rr, req)
+		assert.ErrorIs(t, err, ErrorInvalidTimeRange)
+	})
+
+	t.Run("ranking at time", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/history?at=1690000000", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		err = handler.GetRankingHistory(rr, req)
+		require.NoError(t, err)
+
+		var response httputil.HttpResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		data := response.Data.(map[string]interface{})
+		videos := data["videos"].([]interface{})
+		require.Len(t, videos, 2)
+		assert.Equal(t, "video1", videos[0].(map[string]interface{})["id"])
+		assert.Equal(t, "video2", videos[1].(map[string]interface{})["id"])
+	})
+}
+
+func TestGetRankingMovement(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	snapshotter := NewSnapshotter(handler.redis, logger, time.Minute, 2, time.Hour)
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "100")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "50")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "10")
+	mr.ZAdd("rankings:global", 100, "video1")
+	mr.ZAdd("rankings:global", 50, "video2")
+	mr.ZAdd("rankings:global", 10, "video3")
+
+	t.Run("no snapshot", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 3)
+		for _, video := range response.Data {
+			assert.Empty(t, video.Movement)
+			assert.Nil(t, video.PreviousRank)
+		}
+	})
+
+	require.NoError(t, snapshotter.Snapshot(context.Background(), time.Unix(1690000000, 0)))
+	mr.ZAdd("rankings:global", 75, "video3")
+
+	t.Run("success", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking?offset=1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 2)
+
+		// video3 was outside the stored top 2
+		assert.Equal(t, "video3", response.Data[0].ID)
+		assert.Equal(t, MovementUnknown, response.Data[0].Movement)
+		assert.Nil(t, response.Data[0].PreviousRank)
+
+		assert.Equal(t, "video2", response.Data[1].ID)
+		assert.Equal(t, MovementDown, response.Data[1].Movement)
+		assert.Equal(t, int64(2), *response.Data[1].PreviousRank)
+		assert.Equal(t, int64(-1), *response.Data[1].Delta)
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		require.NoError(t, moderation.SetPin(context.Background(), handler.redis, moderation.Pin{
+			VideoID: "video3", Position: 1, ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		}))
+		defer mr.HDel(moderation.PinsKey, "video3")
+		req, err := http.NewRequest("GET", "/api/v1/ranking", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 3)
+
+		assert.Equal(t, "video3", response.Data[0].ID)
+		assert.True(t, response.Data[0].Pinned)
+		assert.Empty(t, response.Data[0].Movement)
+
+		// the pin above does not push video1 down
+		assert.Equal(t, "video1", response.Data[1].ID)
+		assert.Equal(t, MovementSame, response.Data[1].Movement)
+		assert.Equal(t, "video2", response.Data[2].ID)
+		assert.Equal(t, MovementDown, response.Data[2].Movement)
+	})
+
+	t.Run("complete snapshot", func(t *testing.T) {
+		complete := NewSnapshotter(handler.redis, logger, time.Minute, 10, time.Hour)
+		require.NoError(t, complete.Snapshot(context.Background(), time.Unix(1690000060, 0)))
+		mr.HSet("video:video4", "title", "Video Four", "creator_id", "creator2", "score", "200")
+		mr.ZAdd("rankings:global", 200, "video4")
+
+		req, err := http.NewRequest("GET", "/api/v1/ranking?limit=1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "video4", response.Data[0].ID)
+		assert.Equal(t, MovementNew, response.Data[0].Movement)
+	})
+}
@@ -0,0 +1,352 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"realtime_ranking/internal/moderation"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Review is a video pulled from public boards by reports
+type Review struct {
+	VideoID string `json:"video_id"`
+	Reports int64  `json:"reports"`
+}
+
+// ListReviews returns the videos pending review
+//
+//	@Summary		List videos pending review
+//	@Description	List the videos pulled from public boards after reaching the report threshold
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	httputil.HttpResponse{data=[]handler.Review}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/reviews [get]
+func (h *AdminHandler) ListReviews(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoIDs, err := moderation.PendingReview(ctx, h.redis)
+	if err != nil {
+		h.logger.Error("failed to get videos pending review", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	reviews := make([]Review, 0, len(videoIDs))
+	for _, videoID := range videoIDs {
+		reports, err := moderation.Reports(ctx, h.redis, videoID)
+		if err != nil {
+			h.logger.Error("failed to get reports", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		reviews = append(reviews, Review{VideoID: videoID, Reports: reports})
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: reviews,
+	})
+}
+
+// ClearReview puts a reviewed video back on the public boards
+//
+//	@Summary		Clear a video review
+//	@Description	Put a video pending review back on the public boards and reset its reports
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Video ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.Review}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/reviews/{id} [delete]
+func (h *AdminHandler) ClearReview(w http.ResponseWriter, r *http.Request) error {
+	videoID := r.PathValue("id")
+	reports, err := moderation.Reports(r.Context(), h.redis, videoID)
+	if err != nil {
+		h.logger.Error("failed to get reports", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	cleared, err := moderation.ClearReview(r.Context(), h.redis, videoID)
+	if err != nil {
+		h.logger.Error("failed to clear review", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if !cleared {
+		return ErrorNotUnderReview
+	}
+	h.logger.Info("video review cleared", zap.String("video_id", videoID))
+	if _, err := h.record(r, ActionClearReview, AuditTargetVideo, videoID, "",
+		Review{VideoID: videoID, Reports: reports}, Review{VideoID: videoID}); err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: Review{VideoID: videoID},
+	})
+}
+
+// GetModeration returns the moderated videos and creators
+//
+//	@Summary		Get moderation state
+//	@Description	List the videos pending review, taken down and pinned, and the shadowbanned creators
+//	@Tags			Admin
+//	@Produce		json
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/moderation [get]
+func (h *AdminHandler) GetModeration(w http.ResponseWriter, r *http.Request) error {
+	return h.renderModeration(w, r)
+}
+
+// Takedown removes a video from every board, keeping its score
+//
+//	@Summary		Take down a video
+//	@Description	Remove a video from every board and from the video endpoints; its score keeps being kept up to date
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Video ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/videos/{id}/takedown [post]
+func (h *AdminHandler) Takedown(w http.ResponseWriter, r *http.Request) error {
+	return h.setTakedown(w, r, true)
+}
+
+// RestoreTakedown puts a taken down video back on the boards
+//
+//	@Summary		Restore a taken down video
+//	@Description	Put a taken down video back on the boards with its current score
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Video ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/videos/{id}/takedown [delete]
+func (h *AdminHandler) RestoreTakedown(w http.ResponseWriter, r *http.Request) error {
+	return h.setTakedown(w, r, false)
+}
+
+func (h *AdminHandler) setTakedown(w http.ResponseWriter, r *http.Request, down bool) error {
+	videoID := r.PathValue("id")
+	if err := h.checkVideo(r, videoID); err != nil {
+		return err
+	}
+	changed, err := moderation.SetTakedown(r.Context(), h.redis, videoID, down)
+	if err != nil {
+		h.logger.Error("failed to update takedown", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("video takedown updated", zap.String("video_id", videoID), zap.Bool("down", down), zap.Bool("changed", changed))
+	action := ActionTakedown
+	if !down {
+		action = ActionRestoreTakedown
+	}
+	before := map[string]bool{"takedown": down != changed}
+	if _, err := h.record(r, action, AuditTargetVideo, videoID, "", before, map[string]bool{"takedown": down}); err != nil {
+		return err
+	}
+	return h.renderModeration(w, r)
+}
+
+// Shadowban leaves the videos of a creator out of every board
+//
+//	@Summary		Shadowban a creator
+//	@Description	Leave the videos of a creator out of every board while interactions keep being accepted
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Creator ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/creators/{id}/shadowban [post]
+func (h *AdminHandler) Shadowban(w http.ResponseWriter, r *http.Request) error {
+	return h.setShadowban(w, r, true)
+}
+
+// LiftShadowban puts the videos of a shadowbanned creator back on the boards
+//
+//	@Summary		Lift a creator shadowban
+//	@Description	Put the videos of a shadowbanned creator back on the boards
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Creator ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/creators/{id}/shadowban [delete]
+func (h *AdminHandler) LiftShadowban(w http.ResponseWriter, r *http.Request) error {
+	return h.setShadowban(w, r, false)
+}
+
+func (h *AdminHandler) setShadowban(w http.ResponseWriter, r *http.Request, banned bool) error {
+	creatorID := r.PathValue("id")
+	changed, err := moderation.SetShadowban(r.Context(), h.redis, creatorID, banned)
+	if err != nil {
+		h.logger.Error("failed to update shadowban", zap.String("creator_id", creatorID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("creator shadowban updated", zap.String("creator_id", creatorID), zap.Bool("banned", banned), zap.Bool("changed", changed))
+	action := ActionShadowban
+	if !banned {
+		action = ActionLiftShadowban
+	}
+	before := map[string]bool{"shadowban": banned != changed}
+	if _, err := h.record(r, action, AuditTargetCreator, creatorID, "", before, map[string]bool{"shadowban": banned}); err != nil {
+		return err
+	}
+	return h.renderModeration(w, r)
+}
+
+// PinVideo places a video at a fixed position of the global board
+//
+//	@Summary		Pin a video
+//	@Description	Place a video at a fixed 1-based position of the global ranking until the pin expires
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id			path		string	true	"Video ID"
+//	@Param			position	query		int		true	"1-based position"
+//	@Param			ttl			query		string	false	"Pin duration, e.g. 24h (default: 24h)"
+//	@Success		200			{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		404			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/videos/{id}/pin [post]
+func (h *AdminHandler) PinVideo(w http.ResponseWriter, r *http.Request) error {
+	videoID := r.PathValue("id")
+	position, err := strconv.ParseInt(r.URL.Query().Get("position"), 10, 64)
+	if err != nil || position < 1 {
+		return ErrorInvalidPin
+	}
+	ttl := 24 * time.Hour
+	if v := r.URL.Query().Get("ttl"); v != "" {
+		if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+			return ErrorInvalidPin
+		}
+	}
+	if err := h.checkVideo(r, videoID); err != nil {
+		return err
+	}
+
+	previous, err := h.currentPin(r, videoID)
+	if err != nil {
+		return err
+	}
+	pin := moderation.Pin{VideoID: videoID, Position: position, ExpiresAt: time.Now().Add(ttl).Unix()}
+	if err := moderation.SetPin(r.Context(), h.redis, pin); err != nil {
+		h.logger.Error("failed to pin video", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("video pinned", zap.String("video_id", videoID), zap.Int64("position", position), zap.Duration("ttl", ttl))
+	if _, err := h.record(r, ActionPin, AuditTargetVideo, videoID, "", previous, pin); err != nil {
+		return err
+	}
+	return h.renderModeration(w, r)
+}
+
+// UnpinVideo removes the pin of a video
+//
+//	@Summary		Unpin a video
+//	@Description	Remove the pin of a video, which goes back to its ranked position
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"Video ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=moderation.State}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/videos/{id}/pin [delete]
+func (h *AdminHandler) UnpinVideo(w http.ResponseWriter, r *http.Request) error {
+	videoID := r.PathValue("id")
+	previous, err := h.currentPin(r, videoID)
+	if err != nil {
+		return err
+	}
+	removed, err := moderation.RemovePin(r.Context(), h.redis, videoID)
+	if err != nil {
+		h.logger.Error("failed to unpin video", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if !removed {
+		return ErrorVideoNotFound
+	}
+	h.logger.Info("video unpinned", zap.String("video_id", videoID))
+	if _, err := h.record(r, ActionUnpin, AuditTargetVideo, videoID, "", previous, nil); err != nil {
+		return err
+	}
+	return h.renderModeration(w, r)
+}
+
+// currentPin returns the active pin of a video, nil when it is not pinned
+func (h *AdminHandler) currentPin(r *http.Request, videoID string) (*moderation.Pin, error) {
+	pins, err := moderation.Pins(r.Context(), h.redis, time.Now())
+	if err != nil {
+		h.logger.Error("failed to get pins", zap.Error(err))
+		return nil, ErrorGetDataFailed
+	}
+	for _, pin := range pins {
+		if pin.VideoID == videoID {
+			return &pin, nil
+		}
+	}
+	return nil, nil
+}
+
+// checkVideo returns ErrorVideoNotFound when the video hash does not exist
+func (h *AdminHandler) checkVideo(r *http.Request, videoID string) error {
+	exists, err := h.redis.Exists(r.Context(), fmt.Sprintf("video:%s", videoID)).Result()
+	if err != nil {
+		h.logger.Error("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if exists == 0 {
+		return ErrorVideoNotFound
+	}
+	return nil
+}
+
+func (h *AdminHandler) renderModeration(w http.ResponseWriter, r *http.Request) error {
+	state, err := moderation.GetState(r.Context(), h.redis, time.Now())
+	if err != nil {
+		h.logger.Error("failed to get moderation state", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: state,
+	})
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegativeFeedback(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	admin := &AdminHandler{redis: handler.redis, logger: logger, audit: NewAuditLog(handler.redis)}
+	handler.reportThreshold = 2
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "3")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "2")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "1")
+	mr.ZAdd("rankings:global", 3, "video1")
+	mr.ZAdd("rankings:global", 2, "video2")
+	mr.ZAdd("rankings:global", 1, "video3")
+	mr.ZAdd("creator:creator1:videos", 3, "video1")
+	mr.ZAdd("creator:creator2:videos", 2, "video2")
+	mr.ZAdd("creator:creator2:videos", 1, "video3")
+
+	interact := func(t *testing.T, userID, videoID, interactionType string) {
+		body, _ := json.Marshal(Interaction{VideoID: videoID, Type: interactionType, UserID: userID, Timestamp: 1690000000})
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, handler.UpdateScore(httptest.NewRecorder(), asService(req)))
+	}
+	global := func(t *testing.T) []string {
+		req, err := http.NewRequest("GET", "/api/v1/ranking", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		var videoIDs []string
+		for _, video := range response.Data {
+			videoIDs = append(videoIDs, video.ID)
+		}
+		return videoIDs
+	}
+	personal := func(t *testing.T, userID string) []string {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal?user_id="+userID, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.GetPersonalRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		var videoIDs []string
+		for _, video := range response.Data {
+			videoIDs = append(videoIDs, video.ID)
+		}
+		return videoIDs
+	}
+
+	t.Run("dislike is floored", func(t *testing.T) {
+		interact(t, "user1", "video1", InteractionDislike)
+		score, err := mr.ZScore("rankings:global", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, ScoreFloor, score)
+		creatorScore, err := mr.ZScore("creator:creator1:videos", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, ScoreFloor, creatorScore)
+		assert.Equal(t, "0", mr.HGet("video:video1", "score"))
+		// negative feedback is not a co-interaction
+		assert.False(t, mr.Exists("video:video1:related"))
+	})
+
+	t.Run("hide and not interested", func(t *testing.T) {
+		interact(t, "user2", "video1", InteractionHide)
+		interact(t, "user2", "video2", InteractionNotInterested)
+		assert.Empty(t, personal(t, "user2"))
+		assert.Equal(t, []string{"video3", "video1", "video2"}, personal(t, "user3"))
+	})
+
+	t.Run("report threshold", func(t *testing.T) {
+		interact(t, "user1", "video2", InteractionReport)
+		assert.Equal(t, []string{"video3", "video2", "video1"}, global(t))
+
+		interact(t, "user2", "video2", InteractionReport)
+		assert.Equal(t, []string{"video3", "video1"}, global(t))
+		assert.NotContains(t, personal(t, "user3"), "video2")
+
+		req, err := http.NewRequest("GET", "/api/v1/admin/reviews", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, admin.ListReviews(rr, req))
+		var response struct {
+			Data []Review `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, []Review{{VideoID: "video2", Reports: 2}}, response.Data)
+	})
+
+	t.Run("clear review", func(t *testing.T) {
+		req, err := http.NewRequest("DELETE", "/api/v1/admin/reviews/video2", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video2")
+		require.NoError(t, admin.ClearReview(httptest.NewRecorder(), req))
+		assert.Equal(t, []string{"video3", "video2", "video1"}, global(t))
+
+		assert.Equal(t, ErrorNotUnderReview, admin.ClearReview(httptest.NewRecorder(), req))
+	})
+}
+
+func TestModerationControls(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	admin := &AdminHandler{redis: handler.redis, logger: logger, audit: NewAuditLog(handler.redis)}
+	videoHandler := &VideoHandler{redis: handler.redis, logger: logger, related: handler.related}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "30")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "20")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "10")
+	mr.ZAdd("rankings:global", 30, "video1")
+	mr.ZAdd("rankings:global", 20, "video2")
+	mr.ZAdd("rankings:global", 10, "video3")
+	mr.ZAdd("creator:creator1:videos", 30, "video1")
+	mr.ZAdd("creator:creator2:videos", 20, "video2")
+	mr.ZAdd("creator:creator2:videos", 10, "video3")
+
+	call := func(t *testing.T, endpoint func(http.ResponseWriter, *http.Request) error, method, target, id string) error {
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", id)
+		return endpoint(httptest.NewRecorder(), req)
+	}
+	global := func(t *testing.T) []Video {
+		req, err := http.NewRequest("GET", "/api/v1/ranking", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data
+	}
+	ids := func(videos []Video) []string {
+		var videoIDs []string
+		for _, video := range videos {
+			videoIDs = append(videoIDs, video.ID)
+		}
+		return videoIDs
+	}
+
+	t.Run("takedown", func(t *testing.T) {
+		require.NoError(t, call(t, admin.Takedown, "POST", "/api/v1/admin/videos/video1/takedown", "video1"))
+		assert.Equal(t, []string{"video2", "video3"}, ids(global(t)))
+
+		// the score is preserved
+		score, err := mr.ZScore("rankings:global", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, 30.0, score)
+
+		err = call(t, videoHandler.GetHistory, "GET", "/api/v1/videos/video1/history", "video1")
+		assert.Equal(t, ErrorVideoNotFound, err)
+
+		require.NoError(t, call(t, admin.RestoreTakedown, "DELETE", "/api/v1/admin/videos/video1/takedown", "video1"))
+		assert.Equal(t, []string{"video1", "video2", "video3"}, ids(global(t)))
+
+		err = call(t, admin.Takedown, "POST", "/api/v1/admin/videos/missing/takedown", "missing")
+		assert.Equal(t, ErrorVideoNotFound, err)
+	})
+
+	t.Run("shadowban", func(t *testing.T) {
+		require.NoError(t, call(t, admin.Shadowban, "POST", "/api/v1/admin/creators/creator2/shadowban", "creator2"))
+		assert.Equal(t, []string{"video1"}, ids(global(t)))
+
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal?user_id=user1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.GetPersonalRanking(rr, req))
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, []string{"video1"}, ids(response.Data))
+
+		require.NoError(t, call(t, admin.LiftShadowban, "DELETE", "/api/v1/admin/creators/creator2/shadowban", "creator2"))
+		assert.Len(t, global(t), 3)
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		require.NoError(t, call(t, admin.PinVideo, "POST", "/api/v1/admin/videos/video3/pin?position=1&ttl=1h", "video3"))
+		videos := global(t)
+		assert.Equal(t, []string{"video3", "video1", "video2"}, ids(videos))
+		assert.True(t, videos[0].Pinned)
+		assert.Equal(t, 10.0, videos[0].Score)
+
+		err := call(t, admin.PinVideo, "POST", "/api/v1/admin/videos/video3/pin?position=0", "video3")
+		assert.Equal(t, ErrorInvalidPin, err)
+
+		require.NoError(t, call(t, admin.UnpinVideo, "DELETE", "/api/v1/admin/videos/video3/pin", "video3"))
+		assert.Equal(t, []string{"video1", "video2", "video3"}, ids(global(t)))
+		err = call(t, admin.UnpinVideo, "DELETE", "/api/v1/admin/videos/video3/pin", "video3")
+		assert.Equal(t, ErrorVideoNotFound, err)
+	})
+}
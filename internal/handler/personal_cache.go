@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PersonalCache keeps computed personal rankings for a short TTL so that cursors page
+// through a stable list. Each computation is stored as its own list referenced from the
+// user's index by strategy: invalidating the index makes the next first page recompute,
+// while outstanding cursors keep paging the list they started on until it expires.
+type PersonalCache struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewPersonalCache(redis *redis.Client, ttl time.Duration) *PersonalCache {
+	return &PersonalCache{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+func personalIndexKey(userID string) string {
+	return fmt.Sprintf("personal:%s:index", userID)
+}
+
+func personalListKey(userID, listID string) string {
+	return fmt.Sprintf("personal:%s:list:%s", userID, listID)
+}
+
+// Current returns the cached list of a strategy, empty when there is none
+func (c *PersonalCache) Current(ctx context.Context, userID, strategy string) (string, error) {
+	listID, err := c.redis.HGet(ctx, personalIndexKey(userID), strategy).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return listID, err
+}
+
+// Store caches a computed ranking and makes it the current list of the strategy.
+// Empty rankings are not cached.
+func (c *PersonalCache) Store(ctx context.Context, userID, strategy string, videoIDs []string) (string, error) {
+	if len(videoIDs) == 0 {
+		return "", nil
+	}
+	listID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	listKey := personalListKey(userID, listID)
+	members := make([]interface{}, len(videoIDs))
+	for i, videoID := range videoIDs {
+		members[i] = videoID
+	}
+	_, err := c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, listKey, members...)
+		pipe.Expire(ctx, listKey, c.ttl)
+		pipe.HSet(ctx, personalIndexKey(userID), strategy, listID)
+		pipe.Expire(ctx, personalIndexKey(userID), c.ttl)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return listID, nil
+}
+
+// Page returns up to count videos of a cached list from offset and the list length,
+// ok is false when the list expired
+func (c *PersonalCache) Page(ctx context.Context, userID, listID string, offset, count int64) (videoIDs []string, total int64, ok bool, err error) {
+	listKey := personalListKey(userID, listID)
+	pipe := c.redis.Pipeline()
+	lenCmd := pipe.LLen(ctx, listKey)
+	rangeCmd := pipe.LRange(ctx, listKey, offset, offset+count-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, 0, false, err
+	}
+	if lenCmd.Val() == 0 {
+		return nil, 0, false, nil
+	}
+	return rangeCmd.Val(), lenCmd.Val(), true, nil
+}
+
+// Invalidate makes the next first page of every strategy recompute the user's ranking
+func (c *PersonalCache) Invalidate(ctx context.Context, userID string) error {
+	return c.redis.Del(ctx, personalIndexKey(userID)).Err()
+}
+
+// personalCursor points at the next page of a cached list
+type personalCursor struct {
+	listID string
+	offset int64
+}
+
+func (c personalCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", c.listID, c.offset)))
+}
+
+func parsePersonalCursor(s string) (personalCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return personalCursor{}, err
+	}
+	listID, offset, ok := strings.Cut(string(raw), ":")
+	if !ok || listID == "" {
+		return personalCursor{}, errors.New("malformed cursor")
+	}
+	cursor := personalCursor{listID: listID}
+	if cursor.offset, err = strconv.ParseInt(offset, 10, 64); err != nil || cursor.offset < 0 {
+		return personalCursor{}, errors.New("malformed cursor")
+	}
+	return cursor, nil
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalRankingPagination(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	users := &UserHandler{redis: handler.redis, logger: logger, personal: handler.personal}
+
+	for id, score := range map[string]float64{"video1": 30, "video2": 20, "video3": 10} {
+		mr.HSet("video:"+id, "title", id, "creator_id", "creator_"+id, "score", "0")
+		mr.ZAdd("rankings:global", score, id)
+	}
+
+	type page struct {
+		Data       []Video `json:"data"`
+		NextCursor string  `json:"next_cursor"`
+	}
+	get := func(t *testing.T, query string) (page, error) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal?user_id=user1&limit=2"+query, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		if err := handler.GetPersonalRanking(rr, req); err != nil {
+			return page{}, err
+		}
+		var response page
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response, nil
+	}
+	ids := func(p page) []string {
+		var videoIDs []string
+		for _, video := range p.Data {
+			videoIDs = append(videoIDs, video.ID)
+		}
+		return videoIDs
+	}
+
+	first, err := get(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video1", "video2"}, ids(first))
+	require.NotEmpty(t, first.NextCursor)
+
+	// new scores do not reorder a ranking being paged
+	mr.ZAdd("rankings:global", 100, "video3")
+
+	t.Run("next page", func(t *testing.T) {
+		second, err := get(t, "&cursor="+first.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video3"}, ids(second))
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("first page cached", func(t *testing.T) {
+		again, err := get(t, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video1", "video2"}, ids(again))
+	})
+
+	t.Run("follow invalidates", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/v1/users/user1/follows/creator_video2", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "user1")
+		req.SetPathValue("creator_id", "creator_video2")
+		require.NoError(t, users.Follow(httptest.NewRecorder(), asService(req)))
+		assert.True(t, mr.Exists("user:user1:follows"))
+
+		fresh, err := get(t, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video2", "video3"}, ids(fresh))
+
+		// outstanding cursors keep paging their own list
+		second, err := get(t, "&cursor="+first.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video3"}, ids(second))
+	})
+
+	t.Run("interaction invalidates", func(t *testing.T) {
+		require.True(t, mr.Exists("personal:user1:index"))
+		body, _ := json.Marshal(Interaction{VideoID: "video1", Type: InteractionView, UserID: "user1", Timestamp: 1690000000})
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, handler.UpdateScore(httptest.NewRecorder(), asService(req)))
+		assert.False(t, mr.Exists("personal:user1:index"))
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := get(t, "&cursor=not-a-cursor")
+		assert.Equal(t, ErrorInvalidCursor, err)
+	})
+
+	t.Run("expired cursor", func(t *testing.T) {
+		mr.FastForward(2 * time.Minute)
+		_, err := get(t, "&cursor="+first.NextCursor)
+		assert.Equal(t, ErrorInvalidCursor, err)
+	})
+}
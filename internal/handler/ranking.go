@@ -1,13 +1,19 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"realtime_ranking/internal/experiment"
+	"realtime_ranking/internal/fraud"
+	"realtime_ranking/internal/moderation"
+	"realtime_ranking/internal/ranker"
 	"realtime_ranking/pkg/httputil"
 	"realtime_ranking/pkg/middleware"
-	"sort"
 	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
@@ -20,43 +26,151 @@ const (
 	InteractionComment = "comment"
 	InteractionShare   = "share"
 	InteractionWatch   = "watch"
+
+	// negative feedback
+	InteractionDislike       = "dislike"
+	InteractionSkip          = "skip"
+	InteractionHide          = "hide"           // also hides the video from the user's personal ranking
+	InteractionNotInterested = "not_interested" // also hides the creator from the user's personal ranking
+	InteractionReport        = "report"         // pulls the video from public boards at the report threshold
 )
 
 var scoreIncrements = map[string]float64{
-	InteractionView:    1.0,
-	InteractionLike:    5.0,
-	InteractionComment: 10.0,
-	InteractionShare:   20.0,
-	InteractionWatch:   2.0,
+	InteractionView:          1.0,
+	InteractionLike:          5.0,
+	InteractionComment:       10.0,
+	InteractionShare:         20.0,
+	InteractionWatch:         2.0,
+	InteractionDislike:       -5.0,
+	InteractionSkip:          -1.0,
+	InteractionHide:          -2.0,
+	InteractionNotInterested: -2.0,
+	InteractionReport:        0.0,
 }
 
+// ScoreFloor is the lowest score negative interactions can bring a video to
+const ScoreFloor = 0.0
+
+// incrByWithFloor increments the score of a member without letting it fall below the floor
+var incrByWithFloor = redis.NewScript(`
+local score = tonumber(redis.call('ZINCRBY', KEYS[1], ARGV[1], ARGV[2]))
+if score < tonumber(ARGV[3]) then
+	score = tonumber(ARGV[3])
+	redis.call('ZADD', KEYS[1], score, ARGV[2])
+end
+return tostring(score)
+`)
+
 type RankingHandler struct {
 	redis  *redis.Client
 	logger *zap.Logger
+	events *EventLog
+	rising *RisingBoard
+	// rankers holds the personal ranking strategies
+	rankers     *ranker.Registry
+	experiments *experiment.Set
+	seen        *ranker.SeenHistory
+	related     *ranker.CoInteractionIndex
+	personal    *PersonalCache
+	// fraud decides whether interactions are scored, nil scores them all
+	fraud          *fraud.Detector
+	watchTolerance time.Duration
+	rewatchWindow  time.Duration
+	// audit records the trust penalties of dropped interactions
+	audit        *AuditLog
+	trustPenalty float64
+	// reportThreshold is the number of reporters pulling a video from public boards
+	reportThreshold int64
+}
+
+// RankingOptions are the collaborators of the ranking routes
+type RankingOptions struct {
+	Events      *EventLog
+	Rising      *RisingBoard
+	Rankers     *ranker.Registry
+	Experiments *experiment.Set
+	Seen        *ranker.SeenHistory
+	Related     *ranker.CoInteractionIndex
+	// Personal caches the computed personal rankings for cursor pagination
+	Personal *PersonalCache
+	// Fraud screens interactions before they are scored
+	Fraud *fraud.Detector
+	// WatchTolerance is how much longer than the video duration a watch may be, RewatchWindow
+	// how long rewatches of a video by a user score diminishing returns
+	WatchTolerance time.Duration
+	RewatchWindow  time.Duration
+	// Audit records the automatic trust changes
+	Audit *AuditLog
+	// TrustPenalty is removed from the trust of a user for each dropped interaction
+	TrustPenalty float64
+	// ReportThreshold is the number of reporters pulling a video from public boards, 0 disables it
+	ReportThreshold int64
+	// Limiter applies InteractionLimits to POST /api/v1/interaction, nil disables rate limiting
+	Limiter           *middleware.RateLimiter
+	InteractionLimits RateLimits
 }
 
+// Rank movements against the previous snapshot of a board
+const (
+	MovementUp   = "up"
+	MovementDown = "down"
+	MovementSame = "same"
+	MovementNew  = "new"
+	// MovementUnknown is a video missing from a truncated snapshot, it may have been
+	// ranked below the stored top N
+	MovementUnknown = "unknown"
+)
+
 type Video struct {
 	ID        string  `json:"id"`
 	Title     string  `json:"title"`
 	CreatorID string  `json:"creator_id"`
 	Score     float64 `json:"score"`
+	// PreviousRank is the 1-based rank in the last snapshot, Delta the places climbed since
+	PreviousRank *int64 `json:"previous_rank,omitempty"`
+	Delta        *int64 `json:"delta,omitempty"`
+	Movement     string `json:"movement,omitempty"`
+	// Growth is the score gained in the last rising window minus the window before
+	Growth float64 `json:"growth,omitempty"`
+	// Pinned is true when moderation placed the video at its position
+	Pinned bool `json:"pinned,omitempty"`
+	// Stats are the interaction counters of the video, when requested
+	Stats *VideoStats `json:"stats,omitempty"`
 }
 
 type Interaction struct {
 	VideoID   string `json:"video_id"`
 	Type      string `json:"type"`
-	UserID    string `json:"user_id"`
+	UserID    string `json:"user_id"` // defaults to the authenticated user
 	Timestamp int64  `json:"timestamp"`
-	WatchTime int64  `json:"watch_time,omitempty"` // in seconds
+	WatchTime int64  `json:"watch_time,omitempty"` // in seconds, watches without it count as complete
+	// Weight scales the score of the interaction, the trust of the user times the fraud
+	// detection discount. Screening only lets positive weights through, zero is unset and
+	// means full weight.
+	Weight float64 `json:"-"`
+	// Completion is the share of the video a watch covered and Rewatch the number of earlier
+	// watches of the video by the user in the rewatch window
+	Completion float64 `json:"-"`
+	Rewatch    int64   `json:"-"`
+}
+
+// weight returns the score weight of the interaction
+func (i Interaction) weight() float64 {
+	if i.Weight == 0 {
+		return 1
+	}
+	return i.Weight
 }
 
 // @Summary		Get global video rankings
-// @Description	Retrieve the global ranking of videos based on their scores
+// @Description	Retrieve the global ranking of videos based on their scores, with the rank movement since the last snapshot
 // @Tags			Ranking
 // @Accept			json
 // @Produce		json
 // @Param			limit	query		int	false	"Number of videos to retrieve (default: 10)"
-// @Param			offset	query		int	false	"Offset for pagination (default: 0)"
+// @Param			offset	query		int	false	"Offset for pagination, offset+limit at most 1000 (default: 0)"
+// @Param			user_id	query		string	false	"User ID, enrolls the user in the global ranking experiment"
+// @Param			stats	query		bool	false	"Include the interaction counters of each video"
 //
 // @Success		200		{object}	httputil.HttpResponse{data=[]handler.Video}
 //
@@ -81,12 +195,34 @@ func (h *RankingHandler) GetRanking(w http.ResponseWriter, r *http.Request) erro
 	if offset < 0 {
 		return ErrorOffsetRange
 	}
+	if offset+limit > moderation.MaxDepth {
+		return ErrorPageDepth
+	}
+
+	board := BoardGlobal
+	assigned, variant := h.assignExperiment(experiment.SurfaceGlobal, r.URL.Query().Get("user_id"))
+	if assigned != nil && variant.Strategy == BoardRising {
+		board = BoardRising
+	}
 
-	videoIDs, err := h.redis.ZRevRange(ctx, "rankings:global", int64(offset), int64(offset+limit-1)).Result()
+	var entries []redis.Z
+	var organicRanks map[string]int64
+	if board == BoardRising {
+		entries, err = h.rising.Top(ctx, int64(offset), int64(limit))
+	} else {
+		var pins []moderation.Pin
+		if pins, err = moderation.Pins(ctx, h.redis, time.Now()); err == nil {
+			entries, organicRanks, err = moderation.PinnedRange(ctx, h.redis, "rankings:global", pins, int64(offset), int64(limit))
+		}
+	}
 	if err != nil {
-		h.logger.Error("failed to get rankings", zap.Error(err))
+		h.logger.Error("failed to get rankings", zap.String("board", board), zap.Error(err))
 		return ErrorGetDataFailed
 	}
+	videoIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		videoIDs[i] = entry.Member.(string)
+	}
 
 	var videos []Video
 	for _, videoID := range videoIDs {
@@ -96,33 +232,238 @@ func (h *RankingHandler) GetRanking(w http.ResponseWriter, r *http.Request) erro
 			return ErrorGetDataFailed
 		}
 		score, _ := strconv.ParseFloat(videoData["score"], 64)
+		_, organic := organicRanks[videoID]
 		video := Video{
 			ID:        videoID,
 			Title:     videoData["title"],
 			CreatorID: videoData["creator_id"],
 			Score:     score,
+			Pinned:    board == BoardGlobal && !organic,
 		}
 		videos = append(videos, video)
 	}
+
+	if board == BoardGlobal {
+		previous, err := previousRanks(ctx, h.redis, BoardGlobal, videoIDs)
+		if err != nil {
+			h.logger.Info("failed to get previous ranks", zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		// movement compares organic ranks, the snapshots know nothing of pins
+		if previous != nil {
+			for i := range videos {
+				if rank, ok := organicRanks[videos[i].ID]; ok {
+					setMovement(&videos[i], rank, previous)
+				}
+			}
+		}
+	}
+	if includeStats, _ := strconv.ParseBool(r.URL.Query().Get("stats")); includeStats {
+		if err := withStats(ctx, h.redis, videos); err != nil {
+			h.logger.Info("failed to get video stats", zap.Error(err))
+			return ErrorGetDataFailed
+		}
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code:       http.StatusOK,
+		Data:       videos,
+		Experiment: assigned,
+	})
+}
+
+// assignExperiment enrolls a user in the experiment running on a surface, if any
+func (h *RankingHandler) assignExperiment(surface, userID string) (*httputil.ExperimentInfo, experiment.Variant) {
+	exp, variant, ok := h.experiments.Assign(surface, userID)
+	if !ok {
+		return nil, experiment.Variant{}
+	}
+	h.logger.Info("experiment assignment",
+		zap.String("surface", surface),
+		zap.String("user_id", userID),
+		zap.String("experiment", exp.ID),
+		zap.String("variant", variant.ID),
+	)
+	return &httputil.ExperimentInfo{ID: exp.ID, Variant: variant.ID}, variant
+}
+
+// GetRisingRanking retrieves the videos gaining score the fastest
+//
+//	@Summary		Get rising video rankings
+//	@Description	Retrieve videos ranked by score gained in the last window compared to the previous window
+//	@Tags			Ranking
+//	@Produce		json
+//	@Param			limit	query		int		false	"Number of videos to retrieve (default: 10)"
+//	@Param			offset	query		int		false	"Offset for pagination, offset+limit at most 1000 (default: 0)"
+//	@Param			stats	query		bool	false	"Include the interaction counters of each video"
+//	@Success		200		{object}	httputil.HttpResponse{data=[]handler.Video}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/ranking/rising [get]
+func (h *RankingHandler) GetRisingRanking(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10 // default limit
+	}
+	if limit > 100 || limit < 1 {
+		return ErrorLimitRange
+	}
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil {
+		offset = 0 // default offset
+	}
+	if offset < 0 {
+		return ErrorOffsetRange
+	}
+	if offset+limit > moderation.MaxDepth {
+		return ErrorPageDepth
+	}
+
+	entries, err := h.rising.Top(ctx, int64(offset), int64(limit))
+	if err != nil {
+		h.logger.Error("failed to get rising rankings", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+
+	videos := []Video{}
+	for _, entry := range entries {
+		videoID := entry.Member.(string)
+		videoData, err := h.redis.HGetAll(ctx, fmt.Sprintf("video:%s", videoID)).Result()
+		if err != nil {
+			h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		score, _ := strconv.ParseFloat(videoData["score"], 64)
+		videos = append(videos, Video{
+			ID:        videoID,
+			Title:     videoData["title"],
+			CreatorID: videoData["creator_id"],
+			Score:     score,
+			Growth:    entry.Score,
+		})
+	}
+	if includeStats, _ := strconv.ParseBool(r.URL.Query().Get("stats")); includeStats {
+		if err := withStats(ctx, h.redis, videos); err != nil {
+			h.logger.Info("failed to get video stats", zap.Error(err))
+			return ErrorGetDataFailed
+		}
+	}
 	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
 		Code: http.StatusOK,
 		Data: videos,
 	})
 }
 
+type RankingSnapshot struct {
+	Timestamp int64   `json:"timestamp"`
+	Videos    []Video `json:"videos"`
+}
+
+// GetRankingHistory returns the global board as it was at a point in time
+//
+//	@Summary		Get historical global ranking
+//	@Description	Retrieve the latest global ranking snapshot taken at or before a point in time
+//	@Tags			Ranking
+//	@Produce		json
+//	@Param			at		query		int	false	"Unix timestamp (default: now)"
+//	@Param			limit	query		int	false	"Number of videos to retrieve (default: 10)"
+//	@Success		200		{object}	httputil.HttpResponse{data=handler.RankingSnapshot}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/ranking/history [get]
+func (h *RankingHandler) GetRankingHistory(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10 // default limit
+	}
+	if limit > 100 || limit < 1 {
+		return ErrorLimitRange
+	}
+
+	at := time.Now().Unix()
+	if v := r.URL.Query().Get("at"); v != "" {
+		if at, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return ErrorInvalidTimestamp
+		}
+	}
+
+	timestamp, entries, err := boardAt(ctx, h.redis, BoardGlobal, at, int64(limit))
+	if err != nil {
+		h.logger.Error("failed to get ranking snapshot", zap.Error(err))
+		return ErrorGetDataFailed
+	}
+
+	videos := []Video{}
+	for _, entry := range entries {
+		videoID := entry.Member.(string)
+		videoData, err := h.redis.HGetAll(ctx, fmt.Sprintf("video:%s", videoID)).Result()
+		if err != nil {
+			h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		videos = append(videos, Video{
+			ID:        videoID,
+			Title:     videoData["title"],
+			CreatorID: videoData["creator_id"],
+			Score:     entry.Score,
+		})
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: RankingSnapshot{Timestamp: timestamp, Videos: videos},
+	})
+}
+
+// setMovement fills the rank movement of a video from the ranks of the last snapshot
+func setMovement(video *Video, rank int64, previous *previousSnapshot) {
+	previousRank, ok := previous.ranks[video.ID]
+	if !ok {
+		video.Movement = MovementNew
+		if previous.truncated {
+			video.Movement = MovementUnknown
+		}
+		return
+	}
+	delta := previousRank - rank
+	video.PreviousRank = &previousRank
+	video.Delta = &delta
+	switch {
+	case delta > 0:
+		video.Movement = MovementUp
+	case delta < 0:
+		video.Movement = MovementDown
+	default:
+		video.Movement = MovementSame
+	}
+}
+
 // UpdateScore updates a video's score based on user interaction
 //
 //	@Summary		Update video score
-//	@Description	Update a video's score based on user interaction (e.g., like, comment, share)
+//	@Description	Update a video's score based on user interaction (e.g., like, comment, share).
+//	@Description	user_id defaults to the authenticated user, a different one is rejected unless the caller is a service principal.
+//	@Description	Interactions held by fraud detection are accepted with 202 and not scored.
+//	@Description	Watches score by the share of the video duration watched, with diminishing returns for rewatches.
+//	@Description	Watches are rejected with 400 when watch_time is missing, not positive, or longer than the duration plus tolerance.
 //	@Tags			Interaction
 //	@Accept			json
 //	@Produce		json
 //	@Param			interaction	body		Interaction	true	"User interaction details"
 //
 //	@Success		200			{object}	httputil.HttpResponse{data=object{new_score=number}}
+//	@Success		202			{object}	httputil.HttpResponse{data=object{status=string}}
 //
 //	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		429			{object}	httputil.ErrorResponse
 //	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
 //	@Router			/api/v1/interaction [post]
 func (h *RankingHandler) UpdateScore(w http.ResponseWriter, r *http.Request) error {
 	var interaction Interaction
@@ -130,6 +471,12 @@ func (h *RankingHandler) UpdateScore(w http.ResponseWriter, r *http.Request) err
 		return ErrorInvalidRequestBody
 	}
 
+	userID, err := boundUserID(r, interaction.UserID)
+	if err != nil {
+		return err
+	}
+	interaction.UserID = userID
+
 	if interaction.VideoID == "" || interaction.UserID == "" {
 		return ErrorInvalidRequestBody
 	}
@@ -143,15 +490,10 @@ func (h *RankingHandler) UpdateScore(w http.ResponseWriter, r *http.Request) err
 		return ErrorInvalidTimestamp
 	}
 
-	increment, ok := scoreIncrements[interaction.Type]
-	if !ok {
+	if _, ok := scoreIncrement(interaction); !ok {
 		return ErrorInvalidInteractionType
 	}
 
-	if interaction.Type == InteractionWatch && interaction.WatchTime > 0 {
-		increment *= float64(interaction.WatchTime) / 60.0 // Example: scale by minutes watched
-	}
-
 	ctx := r.Context()
 	videoKey := fmt.Sprintf("video:%s", interaction.VideoID)
 	creatorID, err := h.redis.HGet(ctx, videoKey, "creator_id").Result()
@@ -160,34 +502,39 @@ func (h *RankingHandler) UpdateScore(w http.ResponseWriter, r *http.Request) err
 		return ErrorGetDataFailed
 	}
 
-	// update global ranking
-	globalKey := "rankings:global"
-	newScore, err := h.redis.ZIncrBy(ctx, globalKey, increment, interaction.VideoID).Result()
-	if err != nil {
-		h.logger.Info("failed to update global ranking", zap.Error(err))
-		return ErrorUpdateDataFailed
+	if interaction.Type == InteractionWatch {
+		duration, err := videoDuration(ctx, h.redis, interaction.VideoID)
+		if err != nil {
+			h.logger.Info("failed to get video duration", zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		if !validWatchTime(interaction.WatchTime, duration, h.watchTolerance) {
+			return ErrorInvalidWatchTime
+		}
+		interaction.Completion = watchCompletion(interaction.WatchTime, duration)
+		interaction.WatchTime = capWatchTime(interaction.WatchTime, duration)
 	}
 
-	// update creator-specific ranking
-	creatorKey := fmt.Sprintf("creator:%s:videos", creatorID)
-	_, err = h.redis.ZIncrBy(ctx, creatorKey, increment, interaction.VideoID).Result()
+	trust, err := fraud.Trust(ctx, h.redis, interaction.UserID)
 	if err != nil {
-		h.logger.Info("failed to update creator ranking", zap.Error(err))
-		return ErrorUpdateDataFailed
+		h.logger.Info("failed to get user trust", zap.Error(err))
+		return ErrorGetDataFailed
 	}
-
-	// update score
-	err = h.redis.HSet(ctx, videoKey, "score", newScore).Err()
+	held, err := h.screen(ctx, &interaction, trust)
 	if err != nil {
-		h.logger.Info("failed to update score in video hash", zap.Error(err))
+		h.logger.Info("failed to screen interaction", zap.Error(err))
 		return ErrorUpdateDataFailed
 	}
-
-	interactionKey := fmt.Sprintf("user:%s:interactions", interaction.UserID)
-	err = h.redis.SAdd(ctx, interactionKey, interaction.VideoID).Err()
+	if held {
+		// delayed and dropped interactions get the same answer, not to tell bots apart
+		return httputil.RenderJSON(http.StatusAccepted, w, httputil.HttpResponse{
+			Code: http.StatusAccepted,
+			Data: map[string]interface{}{"status": "pending_review"},
+		})
+	}
+	newScore, err := h.apply(ctx, interaction, creatorID)
 	if err != nil {
-		h.logger.Info("failed to store user interaction", zap.Error(err))
-		return ErrorUpdateDataFailed
+		return err
 	}
 
 	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
@@ -196,18 +543,157 @@ func (h *RankingHandler) UpdateScore(w http.ResponseWriter, r *http.Request) err
 	})
 }
 
+// apply scores an accepted interaction. It is logged first so that rebuilds reproduce it,
+// then the boards, counters and per-user state derived from it are updated. It returns the
+// new global score of the video.
+func (h *RankingHandler) apply(ctx context.Context, interaction Interaction, creatorID string) (float64, error) {
+	// only scored watches count as rewatches, not the ones dropped or still held
+	if interaction.Type == InteractionWatch {
+		rewatch, err := recordWatch(ctx, h.redis, interaction.UserID, interaction.VideoID, h.rewatchWindow)
+		if err != nil {
+			h.logger.Info("failed to record watch", zap.Error(err))
+			return 0, ErrorUpdateDataFailed
+		}
+		interaction.Rewatch = rewatch
+	}
+	increment, ok := scoreIncrement(interaction)
+	if !ok {
+		return 0, ErrorInvalidInteractionType
+	}
+
+	// persist the accepted interaction before deriving rankings from it
+	if err := h.events.Append(ctx, interaction); err != nil {
+		h.logger.Info("failed to append interaction log", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+
+	// update the global and creator rankings and the score in the video hash
+	newScore, err := addScore(ctx, h.redis, interaction.VideoID, creatorID, increment)
+	if err != nil {
+		h.logger.Info("failed to update rankings", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	if err := moderation.Published(ctx, h.redis, interaction.VideoID, creatorID); err != nil {
+		h.logger.Info("failed to update hidden videos", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	if err := h.rising.Record(ctx, interaction.VideoID, increment); err != nil {
+		h.logger.Info("failed to update rising ranking", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+
+	if err := recordStats(ctx, h.redis, interaction); err != nil {
+		h.logger.Info("failed to update video stats", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	if err := recordCreatorActivity(ctx, h.redis, creatorID, interaction, increment, time.Now()); err != nil {
+		h.logger.Info("failed to update creator activity", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	if err := recordContribution(ctx, h.redis, interaction, creatorID, increment); err != nil {
+		h.logger.Info("failed to update user rankings", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+
+	if err := h.applyFeedback(ctx, interaction, creatorID); err != nil {
+		h.logger.Info("failed to apply negative feedback", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+
+	// pair the video with the recently seen ones before it joins the seen history,
+	// negative feedback is no sign of related taste
+	if increment > 0 {
+		if err := h.related.Record(ctx, interaction.UserID, interaction.VideoID); err != nil {
+			h.logger.Info("failed to update co-interactions", zap.Error(err))
+			return 0, ErrorUpdateDataFailed
+		}
+	}
+
+	// update the bounded seen history of the user
+	if err := h.seen.Record(ctx, interaction.UserID, interaction.VideoID, time.Now()); err != nil {
+		h.logger.Info("failed to store user interaction", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	if err := h.personal.Invalidate(ctx, interaction.UserID); err != nil {
+		h.logger.Info("failed to invalidate personal ranking", zap.Error(err))
+		return 0, ErrorUpdateDataFailed
+	}
+	return newScore, nil
+}
+
+// screen runs fraud detection on an interaction and weights it by the trust of the user.
+// Discounted interactions get the product as weight, delayed and dropped ones are stored
+// for review and reported as held.
+func (h *RankingHandler) screen(ctx context.Context, interaction *Interaction, trust float64) (bool, error) {
+	decision := fraud.Decision{Action: fraud.ActionAllow, Weight: 1, Trust: trust}
+	if h.fraud != nil {
+		event := fraud.Event{UserID: interaction.UserID, VideoID: interaction.VideoID, Type: interaction.Type}
+		var err error
+		if decision, err = h.fraud.Evaluate(ctx, event); err != nil {
+			return false, err
+		}
+		decision.Trust = trust
+
+		if decision.Action == fraud.ActionDelay || decision.Action == fraud.ActionDrop {
+			flagged := flaggedInteraction{Interaction: *interaction, Completion: interaction.Completion}
+			id, err := fraud.Store(ctx, h.redis, event, flagged, decision)
+			if err != nil {
+				return false, err
+			}
+			h.logger.Warn("interaction flagged",
+				zap.String("user_id", interaction.UserID),
+				zap.String("video_id", interaction.VideoID),
+				zap.String("action", string(decision.Action)),
+				zap.Strings("rules", decision.Rules),
+				zap.Float64("trust", trust),
+			)
+			// delayed interactions cost trust once rejected on review
+			if decision.Action == fraud.ActionDrop {
+				return true, penalizeTrust(ctx, h.redis, h.audit, FraudActor, interaction.UserID, id, h.trustPenalty)
+			}
+			return true, nil
+		}
+	}
+	if weight := decision.ScoreWeight(); weight != 1 {
+		interaction.Weight = weight
+	}
+	return false, nil
+}
+
+// applyFeedback stores the hide, not interested and report side effects of an interaction
+func (h *RankingHandler) applyFeedback(ctx context.Context, interaction Interaction, creatorID string) error {
+	switch interaction.Type {
+	case InteractionHide:
+		return h.redis.SAdd(ctx, fmt.Sprintf("user:%s:hidden", interaction.UserID), interaction.VideoID).Err()
+	case InteractionNotInterested:
+		return h.redis.SAdd(ctx, fmt.Sprintf("user:%s:not_interested", interaction.UserID), creatorID).Err()
+	case InteractionReport:
+		pulled, err := moderation.Report(ctx, h.redis, interaction.VideoID, interaction.UserID, h.reportThreshold)
+		if err != nil {
+			return err
+		}
+		if pulled {
+			h.logger.Warn("video pulled from public boards pending review", zap.String("video_id", interaction.VideoID))
+		}
+	}
+	return nil
+}
+
 // GetPersonalRanking retrieves a personalized ranking for a user
 //
 //	@Summary		Get personalized video rankings
-//	@Description	Retrieve a personalized ranking of videos for a specific user
+//	@Description	Retrieve a personalized ranking of videos for a specific user, computed by the selected ranking strategy.
+//	@Description	The ranking is cached for a short time; pass next_cursor back as cursor to get the following page.
 //	@Tags			Ranking
 //	@Accept			json
 //	@Produce		json
-//	@Param			user_id	query		string	true	"User ID"
-//	@Param			limit	query		int		false	"Number of videos to retrieve (default: 20)"
-//	@Success		200		{object}	httputil.HttpResponse{data=[]handler.Video}
-//	@Failure		400		{object}	httputil.ErrorResponse
-//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Param			user_id		query		string	true	"User ID"
+//	@Param			limit		query		int		false	"Number of videos to retrieve (default: 20)"
+//	@Param			strategy	query		string	false	"Ranking strategy, bypasses experiments (default: experiment variant or configured strategy)"
+//	@Param			cursor		query		string	false	"Cursor of the next page, from next_cursor of the previous page"
+//	@Success		200			{object}	httputil.HttpResponse{data=[]handler.Video}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
 //	@Router			/api/v1/ranking/personal [get]
 func (h *RankingHandler) GetPersonalRanking(w http.ResponseWriter, r *http.Request) error {
 	userID := r.URL.Query().Get("user_id")
@@ -223,152 +709,274 @@ func (h *RankingHandler) GetPersonalRanking(w http.ResponseWriter, r *http.Reque
 		return ErrorLimitRange
 	}
 
-	ctx := r.Context()
-	followKey := fmt.Sprintf("user:%s:follows", userID)
-	followedCreators, err := h.redis.SMembers(ctx, followKey).Result()
+	selected, err := h.selectStrategy(r, userID)
 	if err != nil {
-		h.logger.Info("failed to get followed creators", zap.Error(err))
-		return ErrorGetDataFailed
+		return err
 	}
 
-	// Fetch user interaction history
-	interactionKey := fmt.Sprintf("user:%s:interactions", userID)
-	interactedVideos, err := h.redis.SMembers(ctx, interactionKey).Result()
-	if err != nil {
-		h.logger.Info("failed to get user interactions", zap.Error(err))
+	ctx := r.Context()
+	// a cursor pages its own list, a first page uses the current list of the strategy
+	var cursor personalCursor
+	paging := r.URL.Query().Get("cursor") != ""
+	if paging {
+		if cursor, err = parsePersonalCursor(r.URL.Query().Get("cursor")); err != nil {
+			return ErrorInvalidCursor
+		}
+	} else if cursor.listID, err = h.personal.Current(ctx, userID, selected.cacheKey()); err != nil {
+		h.logger.Info("failed to get cached personal ranking", zap.String("user_id", userID), zap.Error(err))
 		return ErrorGetDataFailed
 	}
 
-	// Collect candidate videos
-	const topKPerCreator = 10
-	const topMGlobal = 50
-	var candidateVideos []string
-	for _, creatorID := range followedCreators {
-		creatorKey := fmt.Sprintf("creator:%s:videos", creatorID)
-		videos, err := h.redis.ZRevRange(ctx, creatorKey, 0, topKPerCreator-1).Result()
+	var (
+		videoIDs []string
+		total    int64
+		cached   bool
+	)
+	if cursor.listID != "" {
+		videoIDs, total, cached, err = h.personal.Page(ctx, userID, cursor.listID, cursor.offset, int64(limit))
 		if err != nil {
-			h.logger.Info("failed to get videos for creator", zap.String("creator_id", creatorID), zap.Error(err))
+			h.logger.Info("failed to page personal ranking", zap.String("user_id", userID), zap.Error(err))
 			return ErrorGetDataFailed
 		}
-		candidateVideos = append(candidateVideos, videos...)
+		if !cached && paging {
+			return ErrorInvalidCursor
+		}
+	}
+	if !cached {
+		candidates, err := selected.ranker.Rank(ctx, ranker.Request{UserID: userID, Limit: limit, Params: selected.params})
+		if err != nil {
+			h.logger.Info("failed to rank videos", zap.String("user_id", userID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		ranked := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			ranked[i] = candidate.VideoID
+		}
+		cursor = personalCursor{}
+		if cursor.listID, err = h.personal.Store(ctx, userID, selected.cacheKey(), ranked); err != nil {
+			h.logger.Info("failed to cache personal ranking", zap.String("user_id", userID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		videoIDs, total = ranked[:min(limit, len(ranked))], int64(len(ranked))
 	}
 
-	globalVideos, err := h.redis.ZRevRange(ctx, "rankings:global", 0, topMGlobal-1).Result()
+	// videos moderated after the ranking was cached are dropped from the page
+	moderated, err := moderation.HiddenAmong(ctx, h.redis, videoIDs)
 	if err != nil {
-		h.logger.Info("failed to get global rankings", zap.Error(err))
+		h.logger.Info("failed to get hidden videos", zap.Error(err))
 		return ErrorGetDataFailed
 	}
-	candidateVideos = append(candidateVideos, globalVideos...)
 
-	// Remove duplicates
-	videoSet := make(map[string]struct{})
-	for _, videoID := range candidateVideos {
-		videoSet[videoID] = struct{}{}
+	// Fetch video details
+	var videos []Video
+	for _, videoID := range videoIDs {
+		if _, ok := moderated[videoID]; ok {
+			continue
+		}
+		videoKey := fmt.Sprintf("video:%s", videoID)
+		videoData, err := h.redis.HGetAll(ctx, videoKey).Result()
+		if err != nil {
+			h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		score, _ := strconv.ParseFloat(videoData["score"], 64)
+		videos = append(videos, Video{
+			ID:        videoID,
+			Title:     videoData["title"],
+			CreatorID: videoData["creator_id"],
+			Score:     score,
+		})
+	}
+
+	response := httputil.HttpResponse{
+		Code:       http.StatusOK,
+		Data:       videos,
+		Experiment: selected.experiment,
+	}
+	if next := cursor.offset + int64(len(videoIDs)); next < total {
+		response.NextCursor = personalCursor{listID: cursor.listID, offset: next}.String()
+	}
+	return httputil.RenderJSON(http.StatusOK, w, response)
+}
+
+type selectedStrategy struct {
+	name       string
+	ranker     ranker.Ranker
+	params     map[string]float64
+	experiment *httputil.ExperimentInfo
+}
+
+// cacheKey identifies the strategy and the experiment variant parameterizing it
+func (s selectedStrategy) cacheKey() string {
+	if s.experiment == nil {
+		return s.name
 	}
-	var videoIDs []string
-	for videoID := range videoSet {
-		videoIDs = append(videoIDs, videoID)
+	return fmt.Sprintf("%s:%s:%s", s.name, s.experiment.ID, s.experiment.Variant)
+}
+
+// selectStrategy picks the personal ranking strategy of a request. An explicit
+// strategy parameter bypasses the running experiment.
+func (h *RankingHandler) selectStrategy(r *http.Request, userID string) (selectedStrategy, error) {
+	selected := selectedStrategy{name: r.URL.Query().Get("strategy")}
+	if selected.name == "" {
+		var variant experiment.Variant
+		selected.experiment, variant = h.assignExperiment(experiment.SurfacePersonal, userID)
+		selected.name, selected.params = variant.Strategy, variant.Params
+	}
+	selected.name = h.rankers.Resolve(selected.name)
+	strategy, ok := h.rankers.Get(selected.name)
+	if !ok {
+		return selected, ErrorUnknownStrategy
 	}
+	selected.ranker = strategy
+	return selected, nil
+}
 
-	// Fetch scores and creator IDs
-	scores, err := h.redis.ZMScore(ctx, "rankings:global", videoIDs...).Result()
+// Explanation details how a video is scored in a user's personal ranking
+type Explanation struct {
+	UserID     string                   `json:"user_id"`
+	VideoID    string                   `json:"video_id"`
+	Strategy   string                   `json:"strategy"`
+	Experiment *httputil.ExperimentInfo `json:"experiment,omitempty"`
+	// Candidate is false when no candidate source produced the video
+	Candidate  bool           `json:"candidate"`
+	Sources    []string       `json:"sources"`
+	BaseScore  float64        `json:"base_score"`
+	Boosts     []ranker.Boost `json:"boosts"`
+	FinalScore float64        `json:"final_score"`
+	// Rank is the 1-based position among all candidates, 0 when not a candidate
+	Rank int `json:"rank"`
+	// Trust is the factor the user's next interactions are scored with
+	Trust float64 `json:"trust"`
+}
+
+// ExplainPersonalRanking explains the position of a video in a user's personal ranking
+//
+//	@Summary		Explain personalized ranking
+//	@Description	Show the base global score, the boosts applied, the candidate sources and the final score of a video for a user, and the trust factor weighting the user's interactions.
+//	@Description	Users explain their own ranking, service principals and admins any user's.
+//	@Tags			Ranking
+//	@Produce		json
+//	@Param			user_id		query		string	false	"User ID (default: authenticated user)"
+//	@Param			video_id	query		string	true	"Video ID"
+//	@Param			strategy	query		string	false	"Ranking strategy, bypasses experiments (default: experiment variant or configured strategy)"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.Explanation}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/ranking/personal/explain [get]
+func (h *RankingHandler) ExplainPersonalRanking(w http.ResponseWriter, r *http.Request) error {
+	userID, err := viewedUserID(r, r.URL.Query().Get("user_id"))
 	if err != nil {
-		h.logger.Info("failed to get scores", zap.Error(err))
-		return ErrorGetDataFailed
+		return err
+	}
+	if userID == "" {
+		return ErrorUserIDMissing
 	}
-	scoreMap := make(map[string]float64)
-	for i, videoID := range videoIDs {
-		scoreMap[videoID] = scores[i]
+	videoID := r.URL.Query().Get("video_id")
+	if videoID == "" {
+		return ErrorInvalidVideoID
 	}
 
-	pipe := h.redis.Pipeline()
-	creatorCmds := make(map[string]*redis.StringCmd)
-	for videoID := range videoSet {
-		videoKey := fmt.Sprintf("video:%s", videoID)
-		creatorCmds[videoID] = pipe.HGet(ctx, videoKey, "creator_id")
+	selected, err := h.selectStrategy(r, userID)
+	if err != nil {
+		return err
 	}
-	_, err = pipe.Exec(ctx)
+
+	ctx := r.Context()
+	candidates, err := selected.ranker.Rank(ctx, ranker.Request{UserID: userID, Params: selected.params})
 	if err != nil {
-		h.logger.Info("failed to get creator IDs", zap.Error(err))
+		h.logger.Info("failed to rank videos", zap.String("user_id", userID), zap.Error(err))
 		return ErrorGetDataFailed
 	}
-
-	creatorMap := make(map[string]string)
-	for videoID, cmd := range creatorCmds {
-		if creatorID, err := cmd.Result(); err == nil {
-			creatorMap[videoID] = creatorID
-		}
+	trust, err := fraud.Trust(ctx, h.redis, userID)
+	if err != nil {
+		h.logger.Info("failed to get user trust", zap.String("user_id", userID), zap.Error(err))
+		return ErrorGetDataFailed
 	}
 
-	// Apply boosts
-	const followBoost = 100.0
-	const interactionBoost = 50.0
-	var adjustedScores []VideoScore
-	for videoID := range videoSet {
-		score := scoreMap[videoID]
-		if creatorID, ok := creatorMap[videoID]; ok && contains(followedCreators, creatorID) {
-			score += followBoost
+	explanation := Explanation{
+		UserID:     userID,
+		VideoID:    videoID,
+		Strategy:   selected.name,
+		Experiment: selected.experiment,
+		Sources:    []string{},
+		Boosts:     []ranker.Boost{},
+		Trust:      trust,
+	}
+	for i, candidate := range candidates {
+		if candidate.VideoID != videoID {
+			continue
 		}
-		if contains(interactedVideos, videoID) {
-			score += interactionBoost // Boost videos the user has interacted with
+		explanation.Candidate = true
+		explanation.Rank = i + 1
+		explanation.Sources = candidate.Sources
+		explanation.BaseScore = candidate.BaseScore
+		explanation.FinalScore = candidate.Score
+		if candidate.Boosts != nil {
+			explanation.Boosts = candidate.Boosts
 		}
-		adjustedScores = append(adjustedScores, VideoScore{videoID, score})
-	}
-	sort.Slice(adjustedScores, func(i, j int) bool {
-		return adjustedScores[i].Score > adjustedScores[j].Score
-	})
-
-	// Limit to top N
-	if len(adjustedScores) > limit {
-		adjustedScores = adjustedScores[:limit]
+		break
 	}
-
-	// Fetch video details
-	var videos []Video
-	for _, item := range adjustedScores {
-		videoKey := fmt.Sprintf("video:%s", item.VideoID)
-		videoData, err := h.redis.HGetAll(ctx, videoKey).Result()
-		if err != nil {
-			h.logger.Info("failed to get video data", zap.String("video_id", item.VideoID), zap.Error(err))
+	if !explanation.Candidate {
+		score, err := h.redis.ZScore(ctx, "rankings:global", videoID).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			h.logger.Info("failed to get score", zap.String("video_id", videoID), zap.Error(err))
 			return ErrorGetDataFailed
 		}
-		score, _ := strconv.ParseFloat(videoData["score"], 64)
-		videos = append(videos, Video{
-			ID:        item.VideoID,
-			Title:     videoData["title"],
-			CreatorID: videoData["creator_id"],
-			Score:     score,
-		})
+		explanation.BaseScore = score
+		explanation.FinalScore = score
 	}
 
 	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
-		Code: http.StatusOK,
-		Data: videos,
+		Code:       http.StatusOK,
+		Data:       explanation,
+		Experiment: selected.experiment,
 	})
 }
 
-type VideoScore struct {
-	VideoID string
-	Score   float64
-}
-
-// contains checks if a slice contains a string
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
+// scoreIncrement returns the score an interaction adds with the current weights
+func scoreIncrement(interaction Interaction) (float64, bool) {
+	increment, ok := scoreIncrements[interaction.Type]
+	if !ok {
+		return 0, false
+	}
+	if interaction.Type == InteractionWatch {
+		increment *= interaction.watchFactor()
 	}
-	return false
+	return increment * interaction.weight(), true
 }
 
-// NewRankingHandler sets up all routes
-func NewRankingHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger) {
+// NewRankingHandler sets up all routes and returns the handler that scores interactions
+func NewRankingHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger, options RankingOptions) *RankingHandler {
 	handler := &RankingHandler{
-		redis:  redis,
-		logger: logger,
+		redis:       redis,
+		logger:      logger,
+		events:      options.Events,
+		rising:      options.Rising,
+		rankers:     options.Rankers,
+		experiments: options.Experiments,
+		seen:        options.Seen,
+		related:     options.Related,
+		personal:    options.Personal,
+		fraud:       options.Fraud,
+
+		watchTolerance: options.WatchTolerance,
+		rewatchWindow:  options.RewatchWindow,
+		audit:          options.Audit,
+		trustPenalty:   options.TrustPenalty,
+
+		reportThreshold: options.ReportThreshold,
 	}
 	mux.HandleFunc("GET /api/v1/ranking", middleware.WithErrorHandler(handler.GetRanking, logger))
-	mux.HandleFunc("POST /api/v1/interaction", middleware.WithErrorHandler(handler.UpdateScore, logger))
+	updateScore := peekInteraction(options.Limiter.Wrap("interaction", options.InteractionLimits.rules(options.Limiter, interactionUser), handler.UpdateScore))
+	mux.HandleFunc("POST /api/v1/interaction", middleware.WithErrorHandler(middleware.RequireRole(middleware.RoleIngest, updateScore), logger))
 	mux.HandleFunc("GET /api/v1/ranking/personal", middleware.WithErrorHandler(handler.GetPersonalRanking, logger))
+	mux.HandleFunc("GET /api/v1/ranking/personal/explain", middleware.WithErrorHandler(middleware.RequireRole(middleware.RoleIngest, handler.ExplainPersonalRanking), logger))
+	mux.HandleFunc("GET /api/v1/ranking/history", middleware.WithErrorHandler(handler.GetRankingHistory, logger))
+	mux.HandleFunc("GET /api/v1/ranking/rising", middleware.WithErrorHandler(handler.GetRisingRanking, logger))
+	return handler
 }
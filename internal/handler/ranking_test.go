@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"realtime_ranking/internal/experiment"
+	"realtime_ranking/internal/fraud"
+	"realtime_ranking/internal/ranker"
 	"realtime_ranking/pkg/httputil"
+	"realtime_ranking/pkg/middleware"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
@@ -30,6 +35,16 @@ func setupTest(t *testing.T) (*RankingHandler, *miniredis.Miniredis, *zap.Logger
 	handler := &RankingHandler{
 		redis:  client,
 		logger: logger,
+		events: NewEventLog(client, 0),
+		rising: NewRisingBoard(client, time.Hour, 6),
+		rankers: ranker.DefaultRegistry(client, ranker.Config{
+			Strategy:   ranker.StrategyDefault,
+			SeenPolicy: ranker.SeenBoost,
+			SeenWeight: 50,
+		}),
+		seen:     ranker.NewSeenHistory(client, 500, time.Hour),
+		related:  ranker.NewCoInteractionIndex(client, 50, 200),
+		personal: NewPersonalCache(client, time.Minute),
 	}
 
 	return handler, mr, logger
@@ -99,6 +114,16 @@ func TestGetRanking(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 	})
+
+	t.Run("page too deep", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking?offset=100000000", nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, handler.GetRanking(httptest.NewRecorder(), req), ErrorPageDepth)
+
+		req, err = http.NewRequest("GET", "/api/v1/ranking?offset=990&limit=10", nil)
+		require.NoError(t, err)
+		assert.NoError(t, handler.GetRanking(httptest.NewRecorder(), req))
+	})
 }
 
 func TestUpdateScore(t *testing.T) {
@@ -121,7 +146,7 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		err = handler.UpdateScore(rr, req)
+		err = handler.UpdateScore(rr, asService(req))
 		require.NoError(t, err)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
@@ -144,6 +169,10 @@ func TestUpdateScore(t *testing.T) {
 
 		videoScore := mr.HGet(fmt.Sprintf("video:%s", videoID), "score")
 		assert.Equal(t, "5", videoScore)
+
+		seen, err := mr.ZMembers("user:user1:seen")
+		require.NoError(t, err)
+		assert.Equal(t, []string{videoID}, seen)
 	})
 
 	t.Run("success watch with watch time", func(t *testing.T) {
@@ -159,7 +188,7 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		err = handler.UpdateScore(rr, req)
+		err = handler.UpdateScore(rr, asService(req))
 		require.NoError(t, err)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
@@ -170,7 +199,7 @@ func TestUpdateScore(t *testing.T) {
 
 		data, ok := response.Data.(map[string]interface{})
 		assert.True(t, ok)
-		assert.Equal(t, 9.0, data["new_score"])
+		assert.Equal(t, 7.0, data["new_score"])
 	})
 
 	t.Run("invalid interaction type", func(t *testing.T) {
@@ -185,7 +214,7 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		err = handler.UpdateScore(rr, req)
+		err = handler.UpdateScore(rr, asService(req))
 		assert.ErrorIs(t, err, ErrorInvalidInteractionType)
 
 		var response httputil.ErrorResponse
@@ -206,7 +235,7 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		err = handler.UpdateScore(rr, req)
+		err = handler.UpdateScore(rr, asService(req))
 		assert.ErrorIs(t, err, ErrorInvalidVideoID)
 
 		var response httputil.ErrorResponse
@@ -227,7 +256,7 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		rr := httptest.NewRecorder()
 
-		err = handler.UpdateScore(rr, req)
+		err = handler.UpdateScore(rr, asService(req))
 		assert.ErrorIs(t, err, ErrorInvalidTimestamp)
 
 		var response httputil.ErrorResponse
@@ -235,6 +264,30 @@ func TestUpdateScore(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 	})
+
+	t.Run("authenticated user", func(t *testing.T) {
+		submit := func(principal middleware.Principal, userID string) error {
+			body, _ := json.Marshal(Interaction{VideoID: videoID, Type: InteractionView, UserID: userID, Timestamp: 1690000000})
+			req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+			require.NoError(t, err)
+			req = req.WithContext(middleware.WithPrincipal(req.Context(), principal))
+			return handler.UpdateScore(httptest.NewRecorder(), req)
+		}
+		alice := middleware.Principal{ID: "alice", Role: middleware.RoleIngest, Method: middleware.AuthJWT}
+		backend := middleware.Principal{ID: "backend", Role: middleware.RoleIngest, Method: middleware.AuthAPIKey, Service: true}
+
+		// user_id defaults to the authenticated user
+		require.NoError(t, submit(alice, ""))
+		assert.True(t, mr.Exists("user:alice:seen"))
+
+		require.NoError(t, submit(alice, "alice"))
+		assert.ErrorIs(t, submit(alice, "user2"), ErrorUserMismatch)
+		assert.False(t, mr.Exists("user:user2:seen"))
+
+		require.NoError(t, submit(backend, "user2"))
+		assert.True(t, mr.Exists("user:user2:seen"))
+		assert.ErrorIs(t, submit(backend, ""), ErrorInvalidRequestBody)
+	})
 }
 
 func TestGetPersonalRanking(t *testing.T) {
@@ -249,7 +302,7 @@ func TestGetPersonalRanking(t *testing.T) {
 	video3 := "video3"
 
 	mr.SAdd(fmt.Sprintf("user:%s:follows", userID), creator1)
-	mr.SAdd(fmt.Sprintf("user:%s:interactions", userID), video2)
+	mr.ZAdd(fmt.Sprintf("user:%s:seen", userID), 1690000000, video2)
 
 	mr.HSet(fmt.Sprintf("video:%s", video1), "title", "Video One", "creator_id", creator1, "score", "100")
 	mr.HSet(fmt.Sprintf("video:%s", video2), "title", "Video Two", "creator_id", creator2, "score", "80")
@@ -320,3 +373,144 @@ func TestGetPersonalRanking(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 	})
 }
+
+func TestRankingExperiments(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	var err error
+	handler.experiments, err = experiment.NewSet(handler.rankers, experiment.Experiment{
+		ID:      "follow-boost",
+		Salt:    "salt",
+		Surface: experiment.SurfacePersonal,
+		Variants: []experiment.Variant{
+			{ID: "no-follow-boost", Weight: 100, Strategy: ranker.StrategyDefault, Params: map[string]float64{"follow_boost": 0}},
+		},
+	})
+	require.NoError(t, err)
+
+	mr.SAdd("user:user1:follows", "creator1")
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "80")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("rankings:global", 80, "video2")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+	mr.ZAdd("creator:creator2:videos", 80, "video2")
+
+	t.Run("variant applied", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal?user_id=user1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetPersonalRanking(rr, req))
+		var response struct {
+			Data       []Video                  `json:"data"`
+			Experiment *httputil.ExperimentInfo `json:"experiment"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.NotNil(t, response.Experiment)
+		assert.Equal(t, "follow-boost", response.Experiment.ID)
+		assert.Equal(t, "no-follow-boost", response.Experiment.Variant)
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, "video2", response.Data[0].ID)
+	})
+
+	t.Run("explicit strategy bypasses experiment", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal?user_id=user1&strategy=default", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetPersonalRanking(rr, req))
+		var response httputil.HttpResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Nil(t, response.Experiment)
+		videos := response.Data.([]interface{})
+		assert.Equal(t, "video1", videos[0].(map[string]interface{})["id"])
+	})
+
+	t.Run("global not enrolled", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking?user_id=user1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRanking(rr, req))
+		var response httputil.HttpResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Nil(t, response.Experiment)
+	})
+}
+
+func TestExplainPersonalRanking(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	mr.SAdd("user:user1:follows", "creator1")
+	mr.ZAdd("user:user1:seen", 1690000000, "video1")
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "80")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("rankings:global", 80, "video2")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+	mr.ZAdd("creator:creator2:videos", 80, "video2")
+	mr.ZAdd("creator:creator3:videos", 5, "video3")
+
+	explain := func(t *testing.T, query string) Explanation {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal/explain?"+query, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.ExplainPersonalRanking(rr, asService(req)))
+		var response struct {
+			Data Explanation `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		return response.Data
+	}
+
+	t.Run("boosted candidate", func(t *testing.T) {
+		explanation := explain(t, "user_id=user1&video_id=video1")
+		assert.True(t, explanation.Candidate)
+		assert.Equal(t, ranker.StrategyDefault, explanation.Strategy)
+		assert.Equal(t, 1, explanation.Rank)
+		assert.Equal(t, []string{"followed_creators", "global_top"}, explanation.Sources)
+		assert.Equal(t, 10.0, explanation.BaseScore)
+		assert.Equal(t, []ranker.Boost{{Name: "follow", Value: 100}, {Name: "seen", Value: 50}}, explanation.Boosts)
+		assert.Equal(t, 160.0, explanation.FinalScore)
+		assert.Equal(t, fraud.DefaultTrust, explanation.Trust)
+	})
+
+	t.Run("trust", func(t *testing.T) {
+		mr.HSet(fraud.TrustKey, "user1", "0.5")
+		defer mr.HDel(fraud.TrustKey, "user1")
+		explanation := explain(t, "user_id=user1&video_id=video1")
+		assert.Equal(t, 0.5, explanation.Trust)
+	})
+
+	t.Run("not a candidate", func(t *testing.T) {
+		explanation := explain(t, "user_id=user1&video_id=video3&strategy=global")
+		assert.Equal(t, ranker.StrategyGlobal, explanation.Strategy)
+		assert.False(t, explanation.Candidate)
+		assert.Equal(t, 0, explanation.Rank)
+		assert.Empty(t, explanation.Boosts)
+	})
+
+	t.Run("missing video_id", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal/explain?user_id=user1", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		assert.ErrorIs(t, handler.ExplainPersonalRanking(rr, asService(req)), ErrorInvalidVideoID)
+	})
+
+	t.Run("bound to the user", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/personal/explain?user_id=user1&video_id=video1", nil)
+		require.NoError(t, err)
+		as := func(principal middleware.Principal) error {
+			return handler.ExplainPersonalRanking(httptest.NewRecorder(), req.WithContext(middleware.WithPrincipal(req.Context(), principal)))
+		}
+
+		assert.ErrorIs(t, as(middleware.Principal{ID: "user2", Role: middleware.RoleIngest, Method: middleware.AuthJWT}), ErrorUserMismatch)
+		require.NoError(t, as(middleware.Principal{ID: "user1", Role: middleware.RoleIngest, Method: middleware.AuthJWT}))
+		require.NoError(t, as(middleware.Principal{ID: "alice", Role: middleware.RoleAdmin, Method: middleware.AuthAPIKey}))
+	})
+}
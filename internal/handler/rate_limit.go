@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"realtime_ranking/pkg/middleware"
+)
+
+// RateLimits configures the rate limits of an ingestion route, disabled limits are zero
+type RateLimits struct {
+	IP     middleware.Limit
+	APIKey middleware.Limit
+	User   middleware.Limit
+	// Types limits each user per interaction type
+	Types map[string]middleware.Limit
+}
+
+// rules builds the rate rules of a route keyed by client IP, API key and the user returned by user
+func (l RateLimits) rules(limiter *middleware.RateLimiter, user func(r *http.Request) string) []middleware.RateRule {
+	if limiter == nil {
+		return nil
+	}
+	rules := []middleware.RateRule{
+		{Name: "ip", Key: limiter.ClientIP, Limit: l.IP},
+		{Name: "api_key", Key: middleware.APIKeyID, Limit: l.APIKey},
+		{Name: "user", Key: user, Limit: l.User},
+	}
+	for interactionType, limit := range l.Types {
+		rules = append(rules, middleware.RateRule{
+			Name: "user:" + interactionType,
+			Key: func(r *http.Request) string {
+				if peekedInteraction(r).Type != interactionType {
+					return ""
+				}
+				return user(r)
+			},
+			Limit: limit,
+		})
+	}
+	return rules
+}
+
+// maxInteractionBodySize caps the interaction request body read before rate limiting
+const maxInteractionBodySize = 64 << 10
+
+type peekedInteractionKey struct{}
+
+// peekInteraction decodes the interaction of a request body once for the rate rules, keeps it
+// on the request context and restores the body for the handler
+func peekInteraction(next middleware.Endpoint) middleware.Endpoint {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInteractionBodySize))
+		if err != nil {
+			return ErrorInvalidRequestBody
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		var interaction Interaction
+		json.Unmarshal(body, &interaction)
+		return next(w, r.WithContext(context.WithValue(r.Context(), peekedInteractionKey{}, interaction)))
+	}
+}
+
+// peekedInteraction returns the interaction decoded by peekInteraction
+func peekedInteraction(r *http.Request) Interaction {
+	interaction, _ := r.Context().Value(peekedInteractionKey{}).(Interaction)
+	return interaction
+}
+
+// interactionUser is the user an interaction request is limited as
+func interactionUser(r *http.Request) string {
+	userID, err := boundUserID(r, peekedInteraction(r).UserID)
+	if err != nil {
+		return ""
+	}
+	return userID
+}
+
+// followUser is the user a follow request is limited as
+func followUser(r *http.Request) string {
+	userID, err := boundUserID(r, r.PathValue("id"))
+	if err != nil {
+		return ""
+	}
+	return userID
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/pkg/middleware"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractionRateLimits(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+
+	limiter := middleware.NewRateLimiter(handler.redis, logger, 0)
+	limits := RateLimits{
+		User:  middleware.Limit{Rate: 3, Period: time.Minute},
+		Types: map[string]middleware.Limit{InteractionReport: {Rate: 1, Period: time.Hour}},
+	}
+	updateScore := peekInteraction(limiter.Wrap("interaction", limits.rules(limiter, interactionUser), handler.UpdateScore))
+
+	submit := func(userID, interactionType string) (*httptest.ResponseRecorder, error) {
+		body, _ := json.Marshal(Interaction{VideoID: "video1", Type: interactionType, UserID: userID, Timestamp: 1690000000})
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		return rr, updateScore(rr, asService(req))
+	}
+
+	t.Run("per interaction type", func(t *testing.T) {
+		_, err := submit("user1", InteractionReport)
+		require.NoError(t, err)
+
+		rr, err := submit("user1", InteractionReport)
+		assert.Equal(t, middleware.ErrorRateLimited, err)
+		assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+
+		// other types and other users are not limited by the report limit
+		_, err = submit("user1", InteractionLike)
+		require.NoError(t, err)
+		_, err = submit("user2", InteractionReport)
+		require.NoError(t, err)
+	})
+
+	t.Run("per user", func(t *testing.T) {
+		// the rejected report was not counted against the user limit
+		_, err := submit("user1", InteractionLike)
+		require.NoError(t, err)
+		rr, err := submit("user1", InteractionLike)
+		assert.Equal(t, middleware.ErrorRateLimited, err)
+		assert.Equal(t, "3", rr.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+
+		_, err = submit("user2", InteractionLike)
+		require.NoError(t, err)
+	})
+}
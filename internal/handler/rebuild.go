@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"realtime_ranking/internal/fraud"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rebuildSuffix = ":rebuild"
+
+// RebuildResult summarises a ranking rebuild
+type RebuildResult struct {
+	Interactions int `json:"interactions"`
+	Skipped      int `json:"skipped"`
+	Excluded     int `json:"excluded"` // interactions of confirmed bots
+	Videos       int `json:"videos"`
+	Creators     int `json:"creators"`
+}
+
+// RebuildRankings replays the interaction log with the current weights, leaving out the
+// interactions of confirmed bots, adds the manual score adjustments and replaces
+// rankings:global, every creator:<id>:videos set and the score of each video hash.
+// The new sets are built under temporary keys and swapped in a single transaction.
+// It returns ErrLogTrimmed without replaying when the log has been trimmed, since the
+// older interactions would be missing from the rebuilt scores.
+func RebuildRankings(ctx context.Context, rdb *redis.Client, events *EventLog, dryRun bool) (RebuildResult, error) {
+	var result RebuildResult
+	trimmed, err := events.Trimmed(ctx)
+	if err != nil {
+		return result, fmt.Errorf("check interaction log: %w", err)
+	}
+	if trimmed {
+		return result, ErrLogTrimmed
+	}
+	bots, err := fraud.Bots(ctx, rdb)
+	if err != nil {
+		return result, fmt.Errorf("get bots: %w", err)
+	}
+	totals := make(map[string]float64)
+	err = events.Replay(ctx, func(interaction Interaction) error {
+		if _, ok := bots[interaction.UserID]; ok {
+			result.Excluded++
+			return nil
+		}
+		increment, ok := scoreIncrement(interaction)
+		if !ok {
+			result.Skipped++
+			return nil
+		}
+		result.Interactions++
+		totals[interaction.VideoID] = max(totals[interaction.VideoID]+increment, ScoreFloor)
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("replay interaction log: %w", err)
+	}
+
+	// manual score adjustments are not interactions, they are applied on top
+	adjustments, err := rdb.ZRangeWithScores(ctx, adjustmentsKey, 0, -1).Result()
+	if err != nil {
+		return result, fmt.Errorf("get score adjustments: %w", err)
+	}
+	for _, adjustment := range adjustments {
+		videoID := adjustment.Member.(string)
+		totals[videoID] = max(totals[videoID]+adjustment.Score, ScoreFloor)
+	}
+
+	pipe := rdb.Pipeline()
+	creatorCmds := make(map[string]*redis.StringCmd, len(totals))
+	for videoID := range totals {
+		creatorCmds[videoID] = pipe.HGet(ctx, fmt.Sprintf("video:%s", videoID), "creator_id")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return result, fmt.Errorf("get creator ids: %w", err)
+	}
+
+	creatorVideos := make(map[string]map[string]float64)
+	for videoID, cmd := range creatorCmds {
+		creatorID, err := cmd.Result()
+		if err != nil {
+			// the video hash is gone, nothing to rank it under
+			delete(totals, videoID)
+			continue
+		}
+		if creatorVideos[creatorID] == nil {
+			creatorVideos[creatorID] = make(map[string]float64)
+		}
+		creatorVideos[creatorID][videoID] = totals[videoID]
+	}
+	result.Videos = len(totals)
+	result.Creators = len(creatorVideos)
+	if dryRun {
+		return result, nil
+	}
+
+	// stage the rebuilt sets under temporary keys
+	globalKey := "rankings:global"
+	pipe = rdb.Pipeline()
+	pipe.Del(ctx, globalKey+rebuildSuffix)
+	for videoID, score := range totals {
+		pipe.ZAdd(ctx, globalKey+rebuildSuffix, redis.Z{Score: score, Member: videoID})
+	}
+	for creatorID, videos := range creatorVideos {
+		creatorKey := fmt.Sprintf("creator:%s:videos", creatorID) + rebuildSuffix
+		pipe.Del(ctx, creatorKey)
+		for videoID, score := range videos {
+			pipe.ZAdd(ctx, creatorKey, redis.Z{Score: score, Member: videoID})
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return result, fmt.Errorf("stage rebuilt rankings: %w", err)
+	}
+
+	staleCreatorKeys, err := scanKeys(ctx, rdb, "creator:*:videos")
+	if err != nil {
+		return result, fmt.Errorf("scan creator rankings: %w", err)
+	}
+	previousVideos, err := rdb.ZRange(ctx, globalKey, 0, -1).Result()
+	if err != nil {
+		return result, fmt.Errorf("get global rankings: %w", err)
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range staleCreatorKeys {
+			pipe.Del(ctx, key)
+		}
+		pipe.Del(ctx, globalKey)
+		if len(totals) > 0 {
+			pipe.Rename(ctx, globalKey+rebuildSuffix, globalKey)
+		}
+		for creatorID := range creatorVideos {
+			creatorKey := fmt.Sprintf("creator:%s:videos", creatorID)
+			pipe.Rename(ctx, creatorKey+rebuildSuffix, creatorKey)
+		}
+		for _, videoID := range previousVideos {
+			if _, ok := totals[videoID]; !ok {
+				pipe.HSet(ctx, fmt.Sprintf("video:%s", videoID), "score", 0)
+			}
+		}
+		for videoID, score := range totals {
+			pipe.HSet(ctx, fmt.Sprintf("video:%s", videoID), "score", score)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("swap rebuilt rankings: %w", err)
+	}
+	return result, nil
+}
+
+// scanKeys collects all keys matching pattern using SCAN
+func scanKeys(ctx context.Context, rdb *redis.Client, pattern string) ([]string, error) {
+	var keys []string
+	iter := rdb.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
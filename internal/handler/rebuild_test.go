@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildRankings(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "999")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "0")
+
+	// corrupted derived state
+	mr.ZAdd("rankings:global", 999, "video1")
+	mr.ZAdd("creator:creator1:videos", 1, "video1")
+	mr.ZAdd("creator:old:videos", 50, "video2")
+
+	interactions := []Interaction{
+		{VideoID: "video1", Type: InteractionLike, UserID: "user1", Timestamp: 1690000000},
+		{VideoID: "video1", Type: InteractionWatch, UserID: "user2", Timestamp: 1690000001, WatchTime: 120},
+		{VideoID: "video2", Type: InteractionShare, UserID: "user1", Timestamp: 1690000002},
+		{VideoID: "video2", Type: "unknown", UserID: "user1", Timestamp: 1690000003},
+	}
+	for _, interaction := range interactions {
+		require.NoError(t, handler.events.Append(ctx, interaction))
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		var replayed []Interaction
+		err := handler.events.Replay(ctx, func(interaction Interaction) error {
+			replayed = append(replayed, interaction)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, interactions, replayed)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		result, err := RebuildRankings(ctx, handler.redis, handler.events, true)
+		require.NoError(t, err)
+		assert.Equal(t, RebuildResult{Interactions: 3, Skipped: 1, Videos: 2, Creators: 2}, result)
+
+		score, err := mr.ZScore("rankings:global", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, 999.0, score)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		_, err := RebuildRankings(ctx, handler.redis, handler.events, false)
+		require.NoError(t, err)
+
+		score, err := mr.ZScore("rankings:global", "video1")
+		require.NoError(t, err)
+		assert.Equal(t, 7.0, score)
+
+		score, err = mr.ZScore(fmt.Sprintf("creator:%s:videos", "creator1"), "video1")
+		require.NoError(t, err)
+		assert.Equal(t, 7.0, score)
+
+		score, err = mr.ZScore(fmt.Sprintf("creator:%s:videos", "creator2"), "video2")
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, score)
+
+		assert.False(t, mr.Exists("creator:old:videos"))
+		assert.False(t, mr.Exists("rankings:global"+rebuildSuffix))
+		assert.Equal(t, "7", mr.HGet("video:video1", "score"))
+		assert.Equal(t, "20", mr.HGet("video:video2", "score"))
+	})
+
+	t.Run("score adjustments", func(t *testing.T) {
+		mr.ZAdd(adjustmentsKey, -30, "video2")
+
+		_, err := RebuildRankings(ctx, handler.redis, handler.events, false)
+		require.NoError(t, err)
+
+		// 20 - 30 floored
+		score, err := mr.ZScore("rankings:global", "video2")
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, score)
+		assert.Equal(t, "0", mr.HGet("video:video2", "score"))
+	})
+
+	t.Run("trimmed log", func(t *testing.T) {
+		events := NewEventLog(handler.redis, 2)
+		require.NoError(t, events.Append(ctx, interactions[0]))
+
+		_, err := RebuildRankings(ctx, handler.redis, events, true)
+		assert.ErrorIs(t, err, ErrLogTrimmed)
+		score, err := mr.ZScore("rankings:global", "video2")
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, score)
+	})
+}
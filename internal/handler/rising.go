@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"realtime_ranking/internal/moderation"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const risingKey = "rankings:rising"
+
+// RisingBoard ranks videos by how much faster they gained score in the last window than
+// in the window before. Gains are recorded into buckets of window/buckets so both windows
+// slide smoothly; the board is recomputed from the buckets at most once a minute.
+type RisingBoard struct {
+	redis   *redis.Client
+	window  time.Duration
+	buckets int
+	now     func() time.Time
+}
+
+func NewRisingBoard(redis *redis.Client, window time.Duration, buckets int) *RisingBoard {
+	return &RisingBoard{
+		redis:   redis,
+		window:  window,
+		buckets: buckets,
+		now:     time.Now,
+	}
+}
+
+func (b *RisingBoard) bucketSize() time.Duration {
+	return b.window / time.Duration(b.buckets)
+}
+
+func (b *RisingBoard) bucketKey(at time.Time) string {
+	return fmt.Sprintf("rising:bucket:%d", at.Truncate(b.bucketSize()).Unix())
+}
+
+// Record adds a score increment of a video to the current bucket
+func (b *RisingBoard) Record(ctx context.Context, videoID string, increment float64) error {
+	key := b.bucketKey(b.now())
+	_, err := b.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZIncrBy(ctx, key, increment, videoID)
+		// keep the bucket while it is part of the current or previous window
+		pipe.Expire(ctx, key, 2*b.window+b.bucketSize())
+		return nil
+	})
+	return err
+}
+
+// Top returns the videos with the highest positive growth, best first, leaving out the
+// hidden videos
+func (b *RisingBoard) Top(ctx context.Context, offset, limit int64) ([]redis.Z, error) {
+	exists, err := b.redis.Exists(ctx, risingKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		if err := b.compute(ctx); err != nil {
+			return nil, err
+		}
+	}
+	entries, err := moderation.Range(ctx, b.redis, risingKey, nil, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	// growing videos come first, the board ends at the first one without growth
+	for i, entry := range entries {
+		if entry.Score <= 0 {
+			return entries[:i], nil
+		}
+	}
+	return entries, nil
+}
+
+// compute stores gain(last window) - gain(previous window) per video
+func (b *RisingBoard) compute(ctx context.Context) error {
+	now := b.now()
+	store := &redis.ZStore{Aggregate: "SUM"}
+	for i := 0; i < 2*b.buckets; i++ {
+		store.Keys = append(store.Keys, b.bucketKey(now.Add(-time.Duration(i)*b.bucketSize())))
+		weight := 1.0
+		if i >= b.buckets {
+			weight = -1.0
+		}
+		store.Weights = append(store.Weights, weight)
+	}
+	_, err := b.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZUnionStore(ctx, risingKey, store)
+		pipe.Expire(ctx, risingKey, min(b.bucketSize(), time.Minute))
+		return nil
+	})
+	return err
+}
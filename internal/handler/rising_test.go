@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRisingRanking(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	now := time.Unix(1690000000, 0)
+	handler.rising.now = func() time.Time { return now }
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "1000")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "30")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "5")
+
+	// previous window: the established video1 was already gaining
+	now = now.Add(-90 * time.Minute)
+	require.NoError(t, handler.rising.Record(ctx, "video1", 100))
+	require.NoError(t, handler.rising.Record(ctx, "video2", 5))
+
+	// last window: video2 accelerates, video1 steady, video3 slows down to nothing
+	now = now.Add(60 * time.Minute)
+	require.NoError(t, handler.rising.Record(ctx, "video3", 5))
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, handler.rising.Record(ctx, "video1", 100))
+	require.NoError(t, handler.rising.Record(ctx, "video2", 25))
+
+	t.Run("success", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/rising", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		require.NoError(t, handler.GetRisingRanking(rr, req))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, "video2", response.Data[0].ID)
+		assert.Equal(t, 20.0, response.Data[0].Growth)
+		assert.Equal(t, 30.0, response.Data[0].Score)
+		assert.Equal(t, "video3", response.Data[1].ID)
+		assert.Equal(t, 5.0, response.Data[1].Growth)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking/rising?limit=0", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+
+		assert.ErrorIs(t, handler.GetRisingRanking(rr, req), ErrorLimitRange)
+	})
+}
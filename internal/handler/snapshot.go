@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Snapshot record kinds
+const (
+	SnapshotVideo   = "video"   // a video:<id> hash
+	SnapshotGlobal  = "global"  // a rankings:global entry
+	SnapshotCreator = "creator" // a creator:<id>:videos entry
+)
+
+// Supported snapshot encodings
+const (
+	SnapshotJSONL = "jsonl"
+	SnapshotCSV   = "csv"
+)
+
+var snapshotCSVHeader = []string{"kind", "video_id", "creator_id", "score", "fields"}
+
+// SnapshotRecord is a single exported key or sorted set entry
+type SnapshotRecord struct {
+	Kind      string            `json:"kind"`
+	VideoID   string            `json:"video_id"`
+	CreatorID string            `json:"creator_id,omitempty"`
+	Score     float64           `json:"score,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// ParseSnapshotFormat validates a snapshot format, defaulting to JSONL
+func ParseSnapshotFormat(format string) (string, error) {
+	switch format {
+	case "", SnapshotJSONL:
+		return SnapshotJSONL, nil
+	case SnapshotCSV:
+		return SnapshotCSV, nil
+	}
+	return "", ErrorInvalidSnapshotFormat
+}
+
+// ExportSnapshot streams rankings:global, the creator rankings and the video hashes
+// to w. Keys are iterated with SCAN/ZSCAN so the export never loads a whole board.
+func ExportSnapshot(ctx context.Context, rdb *redis.Client, w io.Writer, format string) error {
+	write, flush := snapshotEncoder(w, format)
+
+	if err := zscanRecords(ctx, rdb, "rankings:global", func(videoID string, score float64) error {
+		return write(SnapshotRecord{Kind: SnapshotGlobal, VideoID: videoID, Score: score})
+	}); err != nil {
+		return err
+	}
+
+	creatorIter := rdb.Scan(ctx, 0, "creator:*:videos", 1000).Iterator()
+	for creatorIter.Next(ctx) {
+		key := creatorIter.Val()
+		creatorID := strings.TrimSuffix(strings.TrimPrefix(key, "creator:"), ":videos")
+		if err := zscanRecords(ctx, rdb, key, func(videoID string, score float64) error {
+			return write(SnapshotRecord{Kind: SnapshotCreator, VideoID: videoID, CreatorID: creatorID, Score: score})
+		}); err != nil {
+			return err
+		}
+	}
+	if err := creatorIter.Err(); err != nil {
+		return fmt.Errorf("scan creator rankings: %w", err)
+	}
+
+	videoIter := rdb.Scan(ctx, 0, "video:*", 1000).Iterator()
+	for videoIter.Next(ctx) {
+		videoID := strings.TrimPrefix(videoIter.Val(), "video:")
+		if strings.Contains(videoID, ":") {
+			continue
+		}
+		fields, err := rdb.HGetAll(ctx, videoIter.Val()).Result()
+		if err != nil {
+			return fmt.Errorf("get video %s: %w", videoID, err)
+		}
+		if err := write(SnapshotRecord{Kind: SnapshotVideo, VideoID: videoID, Fields: fields}); err != nil {
+			return err
+		}
+	}
+	if err := videoIter.Err(); err != nil {
+		return fmt.Errorf("scan videos: %w", err)
+	}
+	return flush()
+}
+
+func zscanRecords(ctx context.Context, rdb *redis.Client, key string, fn func(member string, score float64) error) error {
+	iter := rdb.ZScan(ctx, key, 0, "", 1000).Iterator()
+	for iter.Next(ctx) {
+		member := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		score, err := strconv.ParseFloat(iter.Val(), 64)
+		if err != nil {
+			return fmt.Errorf("parse score of %s in %s: %w", member, key, err)
+		}
+		if err := fn(member, score); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("scan %s: %w", key, err)
+	}
+	return nil
+}
+
+func snapshotEncoder(w io.Writer, format string) (write func(SnapshotRecord) error, flush func() error) {
+	if format == SnapshotCSV {
+		writer := csv.NewWriter(w)
+		header := false
+		write = func(record SnapshotRecord) error {
+			if !header {
+				header = true
+				if err := writer.Write(snapshotCSVHeader); err != nil {
+					return err
+				}
+			}
+			fields := ""
+			if record.Fields != nil {
+				raw, err := json.Marshal(record.Fields)
+				if err != nil {
+					return err
+				}
+				fields = string(raw)
+			}
+			return writer.Write([]string{
+				record.Kind, record.VideoID, record.CreatorID,
+				strconv.FormatFloat(record.Score, 'f', -1, 64), fields,
+			})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, flush
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	return func(record SnapshotRecord) error { return encoder.Encode(record) }, buffered.Flush
+}
+
+// ImportResult summarises a snapshot import
+type ImportResult struct {
+	Records int `json:"records"`
+	Keys    int `json:"keys"`
+}
+
+// ImportSnapshot restores a snapshot under prefix (empty for the default keyspace).
+// The target keyspace must be empty. Records are staged under temporary keys and
+// renamed into place in a single transaction, so readers see all or nothing.
+func ImportSnapshot(ctx context.Context, rdb *redis.Client, r io.Reader, format string, prefix string) (ImportResult, error) {
+	var result ImportResult
+	if err := checkSnapshotTarget(ctx, rdb, prefix); err != nil {
+		return result, err
+	}
+
+	staging := fmt.Sprintf("import:%d:", time.Now().UnixNano())
+	staged := make(map[string]struct{})
+	pipe := rdb.Pipeline()
+	flush := func() error {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("stage snapshot: %w", err)
+		}
+		return nil
+	}
+
+	err := decodeSnapshot(r, format, func(record SnapshotRecord) error {
+		var key string
+		switch record.Kind {
+		case SnapshotGlobal:
+			key = "rankings:global"
+			pipe.ZAdd(ctx, staging+prefix+key, redis.Z{Score: record.Score, Member: record.VideoID})
+		case SnapshotCreator:
+			key = fmt.Sprintf("creator:%s:videos", record.CreatorID)
+			pipe.ZAdd(ctx, staging+prefix+key, redis.Z{Score: record.Score, Member: record.VideoID})
+		case SnapshotVideo:
+			if len(record.Fields) == 0 {
+				return nil
+			}
+			key = fmt.Sprintf("video:%s", record.VideoID)
+			pipe.HSet(ctx, staging+prefix+key, record.Fields)
+		default:
+			return fmt.Errorf("unknown snapshot record kind %q", record.Kind)
+		}
+		staged[prefix+key] = struct{}{}
+		result.Records++
+		if result.Records%replayBatchSize == 0 {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		discardStaged(ctx, rdb, staging, staged)
+		return result, err
+	}
+
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key := range staged {
+			pipe.Rename(ctx, staging+key, key)
+		}
+		return nil
+	})
+	if err != nil {
+		discardStaged(ctx, rdb, staging, staged)
+		return result, fmt.Errorf("swap imported snapshot: %w", err)
+	}
+	result.Keys = len(staged)
+	return result, nil
+}
+
+func checkSnapshotTarget(ctx context.Context, rdb *redis.Client, prefix string) error {
+	for _, pattern := range []string{"rankings:global", "creator:*:videos", "video:*"} {
+		iter := rdb.Scan(ctx, 0, prefix+pattern, 1000).Iterator()
+		if iter.Next(ctx) {
+			return ErrorSnapshotTargetNotEmpty
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("check import target: %w", err)
+		}
+	}
+	return nil
+}
+
+func discardStaged(ctx context.Context, rdb *redis.Client, staging string, staged map[string]struct{}) {
+	pipe := rdb.Pipeline()
+	for key := range staged {
+		pipe.Del(ctx, staging+key)
+	}
+	pipe.Exec(ctx)
+}
+
+func decodeSnapshot(r io.Reader, format string, fn func(SnapshotRecord) error) error {
+	if format == SnapshotCSV {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = len(snapshotCSVHeader)
+		for line := 0; ; line++ {
+			row, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("read snapshot: %w", err)
+			}
+			if line == 0 && row[0] == snapshotCSVHeader[0] {
+				continue
+			}
+			record := SnapshotRecord{Kind: row[0], VideoID: row[1], CreatorID: row[2]}
+			if row[3] != "" {
+				if record.Score, err = strconv.ParseFloat(row[3], 64); err != nil {
+					return fmt.Errorf("read snapshot line %d: %w", line+1, err)
+				}
+			}
+			if row[4] != "" {
+				if err := json.Unmarshal([]byte(row[4]), &record.Fields); err != nil {
+					return fmt.Errorf("read snapshot line %d: %w", line+1, err)
+				}
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	decoder := json.NewDecoder(r)
+	for {
+		var record SnapshotRecord
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read snapshot: %w", err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.HSet("video:video2", "title", "Video, Two", "creator_id", "creator2", "score", "2.5")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("rankings:global", 2.5, "video2")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+	mr.ZAdd("creator:creator2:videos", 2.5, "video2")
+
+	for _, format := range []string{SnapshotJSONL, SnapshotCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, ExportSnapshot(ctx, handler.redis, &buf, format))
+
+			prefix := "restore:" + format + ":"
+			result, err := ImportSnapshot(ctx, handler.redis, &buf, format, prefix)
+			require.NoError(t, err)
+			assert.Equal(t, ImportResult{Records: 6, Keys: 5}, result)
+
+			score, err := mr.ZScore(prefix+"rankings:global", "video2")
+			require.NoError(t, err)
+			assert.Equal(t, 2.5, score)
+			score, err = mr.ZScore(prefix+"creator:creator1:videos", "video1")
+			require.NoError(t, err)
+			assert.Equal(t, 10.0, score)
+			assert.Equal(t, "Video, Two", mr.HGet(prefix+"video:video2", "title"))
+			assert.Equal(t, "creator1", mr.HGet(prefix+"video:video1", "creator_id"))
+		})
+	}
+
+	t.Run("target not empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ExportSnapshot(ctx, handler.redis, &buf, SnapshotJSONL))
+
+		_, err := ImportSnapshot(ctx, handler.redis, &buf, SnapshotJSONL, "")
+		assert.ErrorIs(t, err, ErrorSnapshotTargetNotEmpty)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, err := ParseSnapshotFormat("xml")
+		assert.ErrorIs(t, err, ErrorInvalidSnapshotFormat)
+	})
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// VideoStats are the counters of the scored interactions with a video
+type VideoStats struct {
+	VideoID      string `json:"video_id,omitempty"`
+	Views        int64  `json:"views"`
+	Likes        int64  `json:"likes"`
+	Comments     int64  `json:"comments"`
+	Shares       int64  `json:"shares"`
+	Watches      int64  `json:"watches"`
+	WatchSeconds int64  `json:"watch_seconds"`
+	// UniqueViewers is the approximate number of distinct users who viewed or watched the video
+	UniqueViewers int64 `json:"unique_viewers"`
+}
+
+func videoStatsKey(videoID string) string {
+	return fmt.Sprintf("video:%s:stats", videoID)
+}
+
+func videoViewersKey(videoID string) string {
+	return fmt.Sprintf("video:%s:viewers", videoID)
+}
+
+// recordStats counts a scored interaction in the counters of its video
+func recordStats(ctx context.Context, rdb *redis.Client, interaction Interaction) error {
+	pipe := rdb.Pipeline()
+	statsKey := videoStatsKey(interaction.VideoID)
+	pipe.HIncrBy(ctx, statsKey, interaction.Type, 1)
+	if interaction.Type == InteractionWatch {
+		pipe.HIncrBy(ctx, statsKey, "watch_seconds", interaction.WatchTime)
+	}
+	if interaction.Type == InteractionView || interaction.Type == InteractionWatch {
+		pipe.PFAdd(ctx, videoViewersKey(interaction.VideoID), interaction.UserID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// videoStats reads the counters of videos
+func videoStats(ctx context.Context, rdb *redis.Client, videoIDs []string) ([]VideoStats, error) {
+	pipe := rdb.Pipeline()
+	countCmds := make([]*redis.MapStringStringCmd, len(videoIDs))
+	viewerCmds := make([]*redis.IntCmd, len(videoIDs))
+	for i, videoID := range videoIDs {
+		countCmds[i] = pipe.HGetAll(ctx, videoStatsKey(videoID))
+		viewerCmds[i] = pipe.PFCount(ctx, videoViewersKey(videoID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	stats := make([]VideoStats, len(videoIDs))
+	for i, videoID := range videoIDs {
+		counts := countCmds[i].Val()
+		count := func(field string) int64 {
+			v, _ := strconv.ParseInt(counts[field], 10, 64)
+			return v
+		}
+		stats[i] = VideoStats{
+			VideoID:       videoID,
+			Views:         count(InteractionView),
+			Likes:         count(InteractionLike),
+			Comments:      count(InteractionComment),
+			Shares:        count(InteractionShare),
+			Watches:       count(InteractionWatch),
+			WatchSeconds:  count("watch_seconds"),
+			UniqueViewers: viewerCmds[i].Val(),
+		}
+	}
+	return stats, nil
+}
+
+// withStats attaches the counters of each video
+func withStats(ctx context.Context, rdb *redis.Client, videos []Video) error {
+	videoIDs := make([]string, len(videos))
+	for i, video := range videos {
+		videoIDs[i] = video.ID
+	}
+	stats, err := videoStats(ctx, rdb, videoIDs)
+	if err != nil {
+		return err
+	}
+	for i := range videos {
+		stats[i].VideoID = ""
+		videos[i].Stats = &stats[i]
+	}
+	return nil
+}
+
+// GetStats returns the interaction counters of a video
+//
+//	@Summary		Get video stats
+//	@Description	Retrieve the number of scored views, likes, comments, shares and watches of a video, the seconds watched and the approximate number of unique viewers
+//	@Tags			Video
+//	@Produce		json
+//	@Param			id	path		string	true	"Video ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.VideoStats}
+//	@Failure		404	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Router			/api/v1/videos/{id}/stats [get]
+func (h *VideoHandler) GetStats(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoID := r.PathValue("id")
+	exists, err := h.redis.Exists(ctx, fmt.Sprintf("video:%s", videoID)).Result()
+	if err != nil {
+		h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if exists == 0 {
+		return ErrorVideoNotFound
+	}
+	if err := h.checkTakedown(ctx, videoID); err != nil {
+		return err
+	}
+
+	stats, err := videoStats(ctx, h.redis, []string{videoID})
+	if err != nil {
+		h.logger.Info("failed to get video stats", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: stats[0],
+	})
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoStats(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	videos := &VideoHandler{redis: handler.redis, logger: logger}
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0", "duration", "120")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "0")
+
+	interactions := []Interaction{
+		{VideoID: "video1", Type: InteractionView, UserID: "user1"},
+		{VideoID: "video1", Type: InteractionView, UserID: "user1"},
+		{VideoID: "video1", Type: InteractionView, UserID: "user2"},
+		{VideoID: "video1", Type: InteractionLike, UserID: "user3"},
+		{VideoID: "video1", Type: InteractionComment, UserID: "user3"},
+		{VideoID: "video1", Type: InteractionShare, UserID: "user3"},
+		{VideoID: "video1", Type: InteractionWatch, UserID: "user3", WatchTime: 90},
+		{VideoID: "video1", Type: InteractionWatch, UserID: "user1", WatchTime: 30},
+		{VideoID: "video1", Type: InteractionWatch, UserID: "user1", WatchTime: 500},
+		{VideoID: "video2", Type: InteractionLike, UserID: "user1"},
+	}
+	for _, interaction := range interactions {
+		interaction.Timestamp = 1690000000
+		body, _ := json.Marshal(interaction)
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		_ = handler.UpdateScore(httptest.NewRecorder(), asService(req))
+	}
+	expected := VideoStats{
+		VideoID:       "video1",
+		Views:         3,
+		Likes:         1,
+		Comments:      1,
+		Shares:        1,
+		Watches:       2,
+		WatchSeconds:  120,
+		UniqueViewers: 3,
+	}
+
+	t.Run("get stats", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/video1/stats", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "video1")
+		rr := httptest.NewRecorder()
+		require.NoError(t, videos.GetStats(rr, req))
+
+		var response struct {
+			Data VideoStats `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, expected, response.Data)
+	})
+
+	t.Run("unknown video", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/missing/stats", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", "missing")
+		assert.ErrorIs(t, videos.GetStats(httptest.NewRecorder(), req), ErrorVideoNotFound)
+	})
+
+	t.Run("ranking with stats", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/v1/ranking?stats=true", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		require.NoError(t, handler.GetRanking(rr, req))
+
+		var response struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 2)
+		assert.Equal(t, "video1", response.Data[0].ID)
+		expected.VideoID = ""
+		assert.Equal(t, &expected, response.Data[0].Stats)
+		assert.Equal(t, &VideoStats{Likes: 1}, response.Data[1].Stats)
+
+		req, err = http.NewRequest("GET", "/api/v1/ranking", nil)
+		require.NoError(t, err)
+		rr = httptest.NewRecorder()
+		require.NoError(t, handler.GetRanking(rr, req))
+		var plain struct {
+			Data []Video `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&plain))
+		assert.Nil(t, plain.Data[0].Stats)
+	})
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"realtime_ranking/internal/fraud"
+	"realtime_ranking/pkg/httputil"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// UserTrust is the factor multiplying the score of every interaction of a user
+type UserTrust struct {
+	UserID string  `json:"user_id"`
+	Trust  float64 `json:"trust"`
+}
+
+type TrustUpdate struct {
+	Trust  float64 `json:"trust"`
+	Reason string  `json:"reason"`
+}
+
+// penalizeTrust removes a penalty from the trust of a user and records the change in the
+// audit log, reason being the flag behind it
+func penalizeTrust(ctx context.Context, rdb *redis.Client, audit *AuditLog, actor, userID, reason string, penalty float64) error {
+	if penalty == 0 {
+		return nil
+	}
+	before, after, err := fraud.AdjustTrust(ctx, rdb, userID, -penalty)
+	if err != nil || audit == nil || before == after {
+		return err
+	}
+	_, err = audit.Record(ctx, actor, ActionPenalizeTrust, AuditTargetUser, userID, reason,
+		map[string]float64{"trust": before}, map[string]float64{"trust": after})
+	return err
+}
+
+// GetTrust returns the trust factor of a user
+//
+//	@Summary		Get user trust
+//	@Description	Get the factor multiplying the score of every interaction of a user, 1 unless changed
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			id	path		string	true	"User ID"
+//	@Success		200	{object}	httputil.HttpResponse{data=handler.UserTrust}
+//	@Failure		401	{object}	httputil.ErrorResponse
+//	@Failure		403	{object}	httputil.ErrorResponse
+//	@Failure		500	{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/users/{id}/trust [get]
+func (h *AdminHandler) GetTrust(w http.ResponseWriter, r *http.Request) error {
+	userID := r.PathValue("id")
+	trust, err := fraud.Trust(r.Context(), h.redis, userID)
+	if err != nil {
+		h.logger.Error("failed to get user trust", zap.String("user_id", userID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: UserTrust{UserID: userID, Trust: trust},
+	})
+}
+
+// SetTrust replaces the trust factor of a user
+//
+//	@Summary		Set user trust
+//	@Description	Replace the factor multiplying the score of the later interactions of a user, recorded in the audit log with its reason.
+//	@Description	Fraud detection lowers it for every dropped or rejected interaction.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string		true	"User ID"
+//	@Param			trust	body		TrustUpdate	true	"Trust and reason"
+//	@Success		200		{object}	httputil.HttpResponse{data=handler.AuditEntry}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		401		{object}	httputil.ErrorResponse
+//	@Failure		403		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/admin/users/{id}/trust [put]
+func (h *AdminHandler) SetTrust(w http.ResponseWriter, r *http.Request) error {
+	userID := r.PathValue("id")
+	var update TrustUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		return ErrorInvalidRequestBody
+	}
+	if update.Trust < fraud.MinTrust || update.Trust > fraud.MaxTrust || update.Reason == "" {
+		return ErrorInvalidTrust
+	}
+
+	before, err := fraud.SetTrust(r.Context(), h.redis, userID, update.Trust)
+	if err != nil {
+		h.logger.Error("failed to set user trust", zap.String("user_id", userID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	h.logger.Info("user trust set",
+		zap.String("user_id", userID),
+		zap.Float64("before", before),
+		zap.Float64("after", update.Trust),
+		zap.String("reason", update.Reason),
+	)
+	entry, err := h.record(r, ActionSetTrust, AuditTargetUser, userID, update.Reason,
+		map[string]float64{"trust": before}, map[string]float64{"trust": update.Trust})
+	if err != nil {
+		return err
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: entry,
+	})
+}
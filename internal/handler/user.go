@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"realtime_ranking/pkg/middleware"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type UserHandler struct {
+	redis    *redis.Client
+	logger   *zap.Logger
+	personal *PersonalCache
+}
+
+type Follow struct {
+	UserID    string `json:"user_id"`
+	CreatorID string `json:"creator_id"`
+	Following bool   `json:"following"`
+}
+
+// Follow makes a user follow a creator
+//
+//	@Summary		Follow a creator
+//	@Description	Add a creator to the creators a user follows and refresh the user's personal ranking
+//	@Tags			User
+//	@Produce		json
+//	@Param			id			path		string	true	"User ID"
+//	@Param			creator_id	path		string	true	"Creator ID"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.Follow}
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		429			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/users/{id}/follows/{creator_id} [post]
+func (h *UserHandler) Follow(w http.ResponseWriter, r *http.Request) error {
+	return h.setFollow(w, r, true)
+}
+
+// Unfollow makes a user stop following a creator
+//
+//	@Summary		Unfollow a creator
+//	@Description	Remove a creator from the creators a user follows and refresh the user's personal ranking
+//	@Tags			User
+//	@Produce		json
+//	@Param			id			path		string	true	"User ID"
+//	@Param			creator_id	path		string	true	"Creator ID"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.Follow}
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		429			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/users/{id}/follows/{creator_id} [delete]
+func (h *UserHandler) Unfollow(w http.ResponseWriter, r *http.Request) error {
+	return h.setFollow(w, r, false)
+}
+
+// boundUserID returns the user a request acts for. Requests authenticated as a user act for
+// that user, userID defaults to it and any other user is rejected. Service principals act
+// for any userID.
+func boundUserID(r *http.Request, userID string) (string, error) {
+	principal := middleware.PrincipalFrom(r.Context())
+	if userID == "" && !principal.Service {
+		return principal.ID, nil
+	}
+	if !principal.ActsFor(userID) {
+		return "", ErrorUserMismatch
+	}
+	return userID, nil
+}
+
+// viewedUserID returns the user whose private data a read request views. Admins view any
+// user, other principals are bound like writes.
+func viewedUserID(r *http.Request, userID string) (string, error) {
+	if userID != "" && middleware.PrincipalFrom(r.Context()).Role.Includes(middleware.RoleAdmin) {
+		return userID, nil
+	}
+	return boundUserID(r, userID)
+}
+
+func (h *UserHandler) setFollow(w http.ResponseWriter, r *http.Request, following bool) error {
+	ctx := r.Context()
+	userID, err := boundUserID(r, r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	follow := Follow{
+		UserID:    userID,
+		CreatorID: r.PathValue("creator_id"),
+		Following: following,
+	}
+
+	key := fmt.Sprintf("user:%s:follows", follow.UserID)
+	if following {
+		err = h.redis.SAdd(ctx, key, follow.CreatorID).Err()
+	} else {
+		err = h.redis.SRem(ctx, key, follow.CreatorID).Err()
+	}
+	if err != nil {
+		h.logger.Info("failed to update follows", zap.String("user_id", follow.UserID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if err := recordFollow(ctx, h.redis, follow.UserID, follow.CreatorID, following, time.Now()); err != nil {
+		h.logger.Info("failed to update followers", zap.String("creator_id", follow.CreatorID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	if err := h.personal.Invalidate(ctx, follow.UserID); err != nil {
+		h.logger.Info("failed to invalidate personal ranking", zap.String("user_id", follow.UserID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: follow,
+	})
+}
+
+// NewUserHandler sets up the user routes, follows are rate limited by limits when limiter is set
+func NewUserHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger, personal *PersonalCache, limiter *middleware.RateLimiter, limits RateLimits) {
+	handler := &UserHandler{
+		redis:    redis,
+		logger:   logger,
+		personal: personal,
+	}
+	rules := limits.rules(limiter, followUser)
+	mux.HandleFunc("GET /api/v1/users/ranking", middleware.WithErrorHandler(handler.GetUserRanking, logger))
+	mux.HandleFunc("POST /api/v1/users/{id}/follows/{creator_id}", middleware.WithErrorHandler(middleware.RequireRole(middleware.RoleIngest, limiter.Wrap("follow", rules, handler.Follow)), logger))
+	mux.HandleFunc("DELETE /api/v1/users/{id}/follows/{creator_id}", middleware.WithErrorHandler(middleware.RequireRole(middleware.RoleIngest, limiter.Wrap("follow", rules, handler.Unfollow)), logger))
+}
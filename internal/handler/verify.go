@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds of inconsistencies reported by VerifyRankings
+const (
+	MismatchHashScore    = "hash_score"      // video:<id>.score differs from rankings:global
+	MismatchCreatorScore = "creator_score"   // creator:<id>:videos differs from rankings:global
+	MismatchMissingScore = "missing_global"  // scored in the hash but absent from rankings:global
+	MismatchCreator      = "creator_changed" // listed under a creator other than video:<id>.creator_id
+)
+
+const scoreEpsilon = 1e-9
+
+// Mismatch describes a single inconsistency between the ranking keys of a video
+type Mismatch struct {
+	VideoID   string   `json:"video_id"`
+	CreatorID string   `json:"creator_id"`
+	Kind      string   `json:"kind"`
+	Expected  *float64 `json:"expected,omitempty"`
+	Actual    *float64 `json:"actual,omitempty"`
+	// StaleCreatorID is the creator set still holding the video for MismatchCreator
+	StaleCreatorID string `json:"stale_creator_id,omitempty"`
+}
+
+// VerifyReport is the outcome of a consistency check
+type VerifyReport struct {
+	Videos     int        `json:"videos"`
+	Mismatches []Mismatch `json:"mismatches"`
+	Repaired   bool       `json:"repaired"`
+}
+
+// VerifyRankings scans every video hash and compares its score with rankings:global
+// and the creator rankings. rankings:global is treated as the source of truth; when
+// repair is set, the hash and creator sets are rewritten to match it.
+func VerifyRankings(ctx context.Context, rdb *redis.Client, repair bool) (VerifyReport, error) {
+	report := VerifyReport{Mismatches: []Mismatch{}}
+
+	// index every creator set entry by video
+	creatorKeys, err := scanKeys(ctx, rdb, "creator:*:videos")
+	if err != nil {
+		return report, fmt.Errorf("scan creator rankings: %w", err)
+	}
+	creatorEntries := make(map[string]map[string]float64)
+	for _, key := range creatorKeys {
+		creatorID := strings.TrimSuffix(strings.TrimPrefix(key, "creator:"), ":videos")
+		entries, err := rdb.ZRangeWithScores(ctx, key, 0, -1).Result()
+		if err != nil {
+			return report, fmt.Errorf("get creator ranking %s: %w", key, err)
+		}
+		for _, entry := range entries {
+			videoID := entry.Member.(string)
+			if creatorEntries[videoID] == nil {
+				creatorEntries[videoID] = make(map[string]float64)
+			}
+			creatorEntries[videoID][creatorID] = entry.Score
+		}
+	}
+
+	videoKeys, err := scanKeys(ctx, rdb, "video:*")
+	if err != nil {
+		return report, fmt.Errorf("scan videos: %w", err)
+	}
+	for start := 0; start < len(videoKeys); start += replayBatchSize {
+		end := min(start+replayBatchSize, len(videoKeys))
+		mismatches, videos, err := verifyVideos(ctx, rdb, videoKeys[start:end], creatorEntries)
+		if err != nil {
+			return report, err
+		}
+		report.Videos += videos
+		report.Mismatches = append(report.Mismatches, mismatches...)
+	}
+
+	if repair && len(report.Mismatches) > 0 {
+		if err := repairMismatches(ctx, rdb, report.Mismatches); err != nil {
+			return report, fmt.Errorf("repair rankings: %w", err)
+		}
+		report.Repaired = true
+	}
+	return report, nil
+}
+
+func verifyVideos(ctx context.Context, rdb *redis.Client, keys []string, creatorEntries map[string]map[string]float64) ([]Mismatch, int, error) {
+	type videoCmds struct {
+		videoID string
+		hash    *redis.SliceCmd
+		global  *redis.FloatCmd
+	}
+	var cmds []videoCmds
+	pipe := rdb.Pipeline()
+	for _, key := range keys {
+		videoID := strings.TrimPrefix(key, "video:")
+		if strings.Contains(videoID, ":") {
+			// auxiliary per-video keys, not a video hash
+			continue
+		}
+		cmds = append(cmds, videoCmds{
+			videoID: videoID,
+			hash:    pipe.HMGet(ctx, key, "creator_id", "score"),
+			global:  pipe.ZScore(ctx, "rankings:global", videoID),
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, 0, fmt.Errorf("get video data: %w", err)
+	}
+
+	var mismatches []Mismatch
+	for _, cmd := range cmds {
+		values, err := cmd.hash.Result()
+		if err != nil {
+			return nil, 0, fmt.Errorf("get video %s: %w", cmd.videoID, err)
+		}
+		creatorID, _ := values[0].(string)
+		rawScore, _ := values[1].(string)
+		hashScore, _ := strconv.ParseFloat(rawScore, 64)
+
+		globalScore, err := cmd.global.Result()
+		if errors.Is(err, redis.Nil) {
+			if hashScore != 0 {
+				mismatches = append(mismatches, Mismatch{
+					VideoID: cmd.videoID, CreatorID: creatorID, Kind: MismatchMissingScore,
+					Actual: &hashScore,
+				})
+			}
+			globalScore = hashScore
+		} else if err != nil {
+			return nil, 0, fmt.Errorf("get global score %s: %w", cmd.videoID, err)
+		} else if !scoreEqual(hashScore, globalScore) {
+			mismatches = append(mismatches, Mismatch{
+				VideoID: cmd.videoID, CreatorID: creatorID, Kind: MismatchHashScore,
+				Expected: &globalScore, Actual: &hashScore,
+			})
+		}
+
+		entries := creatorEntries[cmd.videoID]
+		if creatorID == "" {
+			continue
+		}
+		if creatorScore, ok := entries[creatorID]; ok && !scoreEqual(creatorScore, globalScore) ||
+			!ok && globalScore != 0 {
+			mismatch := Mismatch{
+				VideoID: cmd.videoID, CreatorID: creatorID, Kind: MismatchCreatorScore,
+				Expected: &globalScore,
+			}
+			if ok {
+				mismatch.Actual = &creatorScore
+			}
+			mismatches = append(mismatches, mismatch)
+		}
+		for staleCreatorID, staleScore := range entries {
+			if staleCreatorID == creatorID {
+				continue
+			}
+			mismatches = append(mismatches, Mismatch{
+				VideoID: cmd.videoID, CreatorID: creatorID, Kind: MismatchCreator,
+				Actual: &staleScore, StaleCreatorID: staleCreatorID,
+			})
+		}
+	}
+	return mismatches, len(cmds), nil
+}
+
+func repairMismatches(ctx context.Context, rdb *redis.Client, mismatches []Mismatch) error {
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, m := range mismatches {
+			videoKey := fmt.Sprintf("video:%s", m.VideoID)
+			creatorKey := fmt.Sprintf("creator:%s:videos", m.CreatorID)
+			switch m.Kind {
+			case MismatchHashScore:
+				pipe.HSet(ctx, videoKey, "score", *m.Expected)
+			case MismatchCreatorScore:
+				pipe.ZAdd(ctx, creatorKey, redis.Z{Score: *m.Expected, Member: m.VideoID})
+			case MismatchMissingScore:
+				pipe.ZAdd(ctx, "rankings:global", redis.Z{Score: *m.Actual, Member: m.VideoID})
+				if m.CreatorID != "" {
+					pipe.ZAdd(ctx, creatorKey, redis.Z{Score: *m.Actual, Member: m.VideoID})
+				}
+			case MismatchCreator:
+				pipe.ZRem(ctx, fmt.Sprintf("creator:%s:videos", m.StaleCreatorID), m.VideoID)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func scoreEqual(a, b float64) bool {
+	return math.Abs(a-b) < scoreEpsilon
+}
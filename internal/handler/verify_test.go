@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRankings(t *testing.T) {
+	handler, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	// consistent
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+	// hash and creator set drifted, old creator still lists it
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "7")
+	mr.ZAdd("rankings:global", 20, "video2")
+	mr.ZAdd("creator:creator2:videos", 15, "video2")
+	mr.ZAdd("creator:creator1:videos", 5, "video2")
+	// never interacted with
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator1", "score", "0")
+
+	t.Run("report", func(t *testing.T) {
+		report, err := VerifyRankings(ctx, handler.redis, false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, report.Videos)
+		assert.False(t, report.Repaired)
+
+		kinds := make(map[string]Mismatch)
+		for _, m := range report.Mismatches {
+			assert.Equal(t, "video2", m.VideoID)
+			kinds[m.Kind] = m
+		}
+		require.Len(t, kinds, 3)
+		assert.Equal(t, 20.0, *kinds[MismatchHashScore].Expected)
+		assert.Equal(t, 7.0, *kinds[MismatchHashScore].Actual)
+		assert.Equal(t, 15.0, *kinds[MismatchCreatorScore].Actual)
+		assert.Equal(t, "creator1", kinds[MismatchCreator].StaleCreatorID)
+	})
+
+	t.Run("repair", func(t *testing.T) {
+		report, err := VerifyRankings(ctx, handler.redis, true)
+		require.NoError(t, err)
+		assert.True(t, report.Repaired)
+
+		assert.Equal(t, "20", mr.HGet("video:video2", "score"))
+		score, err := mr.ZScore("creator:creator2:videos", "video2")
+		require.NoError(t, err)
+		assert.Equal(t, 20.0, score)
+		members, err := mr.ZMembers("creator:creator1:videos")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video1"}, members)
+
+		report, err = VerifyRankings(ctx, handler.redis, false)
+		require.NoError(t, err)
+		assert.Empty(t, report.Mismatches)
+	})
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/internal/moderation"
+	"realtime_ranking/internal/ranker"
+	"realtime_ranking/pkg/httputil"
+	"realtime_ranking/pkg/middleware"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type VideoHandler struct {
+	redis   *redis.Client
+	logger  *zap.Logger
+	related *ranker.CoInteractionIndex
+}
+
+type VideoHistory struct {
+	VideoID string         `json:"video_id"`
+	Board   string         `json:"board"`
+	Points  []HistoryPoint `json:"points"`
+}
+
+// GetHistory returns the rank and score of a video over time
+//
+//	@Summary		Get video rank history
+//	@Description	Retrieve the rank and score time series of a video from the periodic ranking snapshots
+//	@Tags			Video
+//	@Produce		json
+//	@Param			id		path		string	true	"Video ID"
+//	@Param			board	query		string	false	"global (default) or creator"
+//	@Param			from	query		int		false	"Start unix timestamp (default: 24 hours before to)"
+//	@Param			to		query		int		false	"End unix timestamp (default: now)"
+//	@Success		200		{object}	httputil.HttpResponse{data=handler.VideoHistory}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		404		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/videos/{id}/history [get]
+func (h *VideoHandler) GetHistory(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoID := r.PathValue("id")
+
+	from, to, err := parseTimeRange(r, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if err := h.checkTakedown(ctx, videoID); err != nil {
+		return err
+	}
+
+	board := r.URL.Query().Get("board")
+	switch board {
+	case "", BoardGlobal:
+		board = BoardGlobal
+	case "creator":
+		creatorID, err := h.redis.HGet(ctx, fmt.Sprintf("video:%s", videoID), "creator_id").Result()
+		if errors.Is(err, redis.Nil) {
+			return ErrorVideoNotFound
+		}
+		if err != nil {
+			h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		board = "creator:" + creatorID
+	default:
+		return ErrorInvalidBoard
+	}
+
+	points, err := videoHistory(ctx, h.redis, board, videoID, from, to)
+	if err != nil {
+		h.logger.Info("failed to get video history", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: VideoHistory{VideoID: videoID, Board: board, Points: points},
+	})
+}
+
+// RelatedVideo is a video co-interacted with another one
+type RelatedVideo struct {
+	Video
+	// CoInteractions is the number of users who interacted with both videos
+	CoInteractions float64 `json:"co_interactions"`
+}
+
+// GetRelated returns the videos users who interacted with a video also interacted with
+//
+//	@Summary		Get related videos
+//	@Description	Retrieve the videos most often interacted with by the users who interacted with a video
+//	@Tags			Video
+//	@Produce		json
+//	@Param			id		path		string	true	"Video ID"
+//	@Param			limit	query		int		false	"Number of videos to retrieve (default: 10)"
+//	@Success		200		{object}	httputil.HttpResponse{data=[]handler.RelatedVideo}
+//	@Failure		400		{object}	httputil.ErrorResponse
+//	@Failure		404		{object}	httputil.ErrorResponse
+//	@Failure		500		{object}	httputil.ErrorResponse
+//	@Router			/api/v1/videos/{id}/related [get]
+func (h *VideoHandler) GetRelated(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoID := r.PathValue("id")
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 10
+	}
+	if limit > 100 || limit < 1 {
+		return ErrorLimitRange
+	}
+
+	exists, err := h.redis.Exists(ctx, fmt.Sprintf("video:%s", videoID)).Result()
+	if err != nil {
+		h.logger.Info("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if exists == 0 {
+		return ErrorVideoNotFound
+	}
+	if err := h.checkTakedown(ctx, videoID); err != nil {
+		return err
+	}
+
+	entries, err := h.related.Related(ctx, videoID, 0, int64(limit))
+	if err != nil {
+		h.logger.Info("failed to get related videos", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	videos := make([]RelatedVideo, 0, len(entries))
+	for _, entry := range entries {
+		relatedID := entry.Member.(string)
+		videoData, err := h.redis.HGetAll(ctx, fmt.Sprintf("video:%s", relatedID)).Result()
+		if err != nil {
+			h.logger.Info("failed to get video data", zap.String("video_id", relatedID), zap.Error(err))
+			return ErrorGetDataFailed
+		}
+		score, _ := strconv.ParseFloat(videoData["score"], 64)
+		videos = append(videos, RelatedVideo{
+			Video: Video{
+				ID:        relatedID,
+				Title:     videoData["title"],
+				CreatorID: videoData["creator_id"],
+				Score:     score,
+			},
+			CoInteractions: entry.Score,
+		})
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: videos,
+	})
+}
+
+// checkTakedown hides taken down videos as if they did not exist
+func (h *VideoHandler) checkTakedown(ctx context.Context, videoID string) error {
+	down, err := moderation.IsTakenDown(ctx, h.redis, videoID)
+	if err != nil {
+		h.logger.Info("failed to get moderation state", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if down {
+		return ErrorVideoNotFound
+	}
+	return nil
+}
+
+// parseTimeRange reads the from/to unix timestamp query parameters. to defaults to now
+// and from defaults to window before to.
+func parseTimeRange(r *http.Request, window time.Duration) (int64, int64, error) {
+	to := time.Now().Unix()
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, ErrorInvalidTimeRange
+		}
+		to = parsed
+	}
+	from := to - int64(window.Seconds())
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, ErrorInvalidTimeRange
+		}
+		from = parsed
+	}
+	if from > to {
+		return 0, 0, ErrorInvalidTimeRange
+	}
+	return from, to, nil
+}
+
+// NewVideoHandler sets up the per-video routes
+func NewVideoHandler(mux *http.ServeMux, redis *redis.Client, logger *zap.Logger, related *ranker.CoInteractionIndex) {
+	handler := &VideoHandler{
+		redis:   redis,
+		logger:  logger,
+		related: related,
+	}
+	mux.HandleFunc("GET /api/v1/videos/{id}/history", middleware.WithErrorHandler(handler.GetHistory, logger))
+	mux.HandleFunc("GET /api/v1/videos/{id}/related", middleware.WithErrorHandler(handler.GetRelated, logger))
+	mux.HandleFunc("GET /api/v1/videos/{id}/stats", middleware.WithErrorHandler(handler.GetStats, logger))
+	mux.HandleFunc("PUT /api/v1/videos/{id}/duration", middleware.WithErrorHandler(middleware.RequireService(middleware.RoleIngest, handler.SetDuration), logger))
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRelated(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	videoHandler := &VideoHandler{redis: handler.redis, logger: logger, related: handler.related}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "0")
+	for _, interaction := range []Interaction{
+		{VideoID: "video1", Type: InteractionView, UserID: "user1", Timestamp: 1690000000},
+		{VideoID: "video2", Type: InteractionLike, UserID: "user1", Timestamp: 1690000000},
+	} {
+		body, _ := json.Marshal(interaction)
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, handler.UpdateScore(httptest.NewRecorder(), asService(req)))
+	}
+
+	get := func(videoID string) (*httptest.ResponseRecorder, error) {
+		req, err := http.NewRequest("GET", "/api/v1/videos/"+videoID+"/related", nil)
+		require.NoError(t, err)
+		req.SetPathValue("id", videoID)
+		rr := httptest.NewRecorder()
+		return rr, videoHandler.GetRelated(rr, req)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rr, err := get("video1")
+		require.NoError(t, err)
+
+		var response struct {
+			Data []RelatedVideo `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, "video2", response.Data[0].ID)
+		assert.Equal(t, "Video Two", response.Data[0].Title)
+		assert.Equal(t, 5.0, response.Data[0].Score)
+		assert.Equal(t, 1.0, response.Data[0].CoInteractions)
+	})
+
+	t.Run("unknown video", func(t *testing.T) {
+		_, err := get("missing")
+		assert.Equal(t, ErrorVideoNotFound, err)
+	})
+}
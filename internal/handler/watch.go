@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Watch scoring. A watch scores the watch increment times the share of the video watched,
+// capped at a complete watch, and each rewatch within the rewatch window scores RewatchDecay
+// times the previous one.
+const (
+	RewatchDecay = 0.5
+	// defaultWatchDuration is a complete watch of videos without a duration, in seconds
+	defaultWatchDuration = 60
+)
+
+type VideoDuration struct {
+	Duration int64 `json:"duration"` // in seconds
+}
+
+// flaggedInteraction is an interaction held by fraud detection along with its watch completion,
+// which is not part of the submitted interaction. Rewatches are counted once it is scored.
+type flaggedInteraction struct {
+	Interaction
+	Completion float64 `json:"completion,omitempty"`
+}
+
+func (f flaggedInteraction) interaction() Interaction {
+	interaction := f.Interaction
+	interaction.Completion = f.Completion
+	return interaction
+}
+
+// watchCompletion returns the share of a video watched, capped at a complete watch. Watches
+// without a watch time count as complete, as they scored before watch times were reported.
+func watchCompletion(watchTime, duration int64) float64 {
+	if watchTime <= 0 {
+		return 1
+	}
+	if duration <= 0 {
+		duration = defaultWatchDuration
+	}
+	return min(float64(watchTime)/float64(duration), 1)
+}
+
+// watchFactor scales the watch increment by completion and rewatches
+func (i Interaction) watchFactor() float64 {
+	completion := i.Completion
+	if completion == 0 {
+		// watches logged before completions were recorded
+		completion = watchCompletion(i.WatchTime, 0)
+	}
+	return completion * math.Pow(RewatchDecay, float64(i.Rewatch))
+}
+
+// videoDuration returns the duration of a video in seconds, 0 when unknown
+func videoDuration(ctx context.Context, rdb *redis.Client, videoID string) (int64, error) {
+	duration, err := rdb.HGet(ctx, fmt.Sprintf("video:%s", videoID), "duration").Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return duration, err
+}
+
+// validWatchTime rejects negative watch times and the ones exceeding the known duration of
+// the video by more than the tolerance. A zero watch time is a watch without one.
+func validWatchTime(watchTime, duration int64, tolerance time.Duration) bool {
+	if watchTime < 0 {
+		return false
+	}
+	return duration <= 0 || watchTime <= duration+int64(tolerance.Seconds())
+}
+
+// capWatchTime caps a watch time at a complete watch, the default duration for videos
+// without one, so the watch seconds of the stats stay bounded like the score
+func capWatchTime(watchTime, duration int64) int64 {
+	if duration <= 0 {
+		duration = defaultWatchDuration
+	}
+	return min(watchTime, duration)
+}
+
+// recordWatch counts a watch of a video by a user and returns the number of earlier watches
+// since the first one of the rewatch window, a zero window counts them all. EXPIRE NX needs
+// Redis 7.
+func recordWatch(ctx context.Context, rdb *redis.Client, userID, videoID string, window time.Duration) (int64, error) {
+	key := fmt.Sprintf("user:%s:watches:%s", userID, videoID)
+	pipe := rdb.Pipeline()
+	count := pipe.Incr(ctx, key)
+	if window > 0 {
+		pipe.ExpireNX(ctx, key, window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val() - 1, nil
+}
+
+// SetDuration stores the duration of a video
+//
+//	@Summary		Set video duration
+//	@Description	Store the duration of a video, watches are scored by the share of it watched and rejected when longer than it plus the tolerance.
+//	@Description	Only service principals and admins can set it.
+//	@Tags			Video
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string			true	"Video ID"
+//	@Param			duration	body		VideoDuration	true	"Duration in seconds"
+//	@Success		200			{object}	httputil.HttpResponse{data=handler.VideoDuration}
+//	@Failure		400			{object}	httputil.ErrorResponse
+//	@Failure		401			{object}	httputil.ErrorResponse
+//	@Failure		403			{object}	httputil.ErrorResponse
+//	@Failure		404			{object}	httputil.ErrorResponse
+//	@Failure		500			{object}	httputil.ErrorResponse
+//	@Security		ApiKeyAuth
+//	@Security		BearerAuth
+//	@Router			/api/v1/videos/{id}/duration [put]
+func (h *VideoHandler) SetDuration(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	videoID := r.PathValue("id")
+	var duration VideoDuration
+	if err := json.NewDecoder(r.Body).Decode(&duration); err != nil {
+		return ErrorInvalidRequestBody
+	}
+	if duration.Duration <= 0 {
+		return ErrorInvalidDuration
+	}
+
+	videoKey := fmt.Sprintf("video:%s", videoID)
+	exists, err := h.redis.Exists(ctx, videoKey).Result()
+	if err != nil {
+		h.logger.Error("failed to get video data", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorGetDataFailed
+	}
+	if exists == 0 {
+		return ErrorVideoNotFound
+	}
+	if err := h.redis.HSet(ctx, videoKey, "duration", duration.Duration).Err(); err != nil {
+		h.logger.Error("failed to set video duration", zap.String("video_id", videoID), zap.Error(err))
+		return ErrorUpdateDataFailed
+	}
+	return httputil.RenderJSON(http.StatusOK, w, httputil.HttpResponse{
+		Code: http.StatusOK,
+		Data: duration,
+	})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"realtime_ranking/internal/fraud"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchScoring(t *testing.T) {
+	handler, mr, logger := setupTest(t)
+	defer mr.Close()
+
+	handler.watchTolerance = 10 * time.Second
+	handler.rewatchWindow = time.Hour
+	videos := &VideoHandler{redis: handler.redis, logger: logger}
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "0")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator1", "score", "0")
+
+	setDuration := func(t *testing.T, videoID string, duration int64) error {
+		body, _ := json.Marshal(VideoDuration{Duration: duration})
+		req, err := http.NewRequest("PUT", "/api/v1/videos/"+videoID+"/duration", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.SetPathValue("id", videoID)
+		return videos.SetDuration(httptest.NewRecorder(), req)
+	}
+	watch := func(t *testing.T, userID, videoID string, watchTime int64) error {
+		body, _ := json.Marshal(Interaction{VideoID: videoID, Type: InteractionWatch, UserID: userID, Timestamp: 1690000000, WatchTime: watchTime})
+		req, err := http.NewRequest("POST", "/api/v1/interaction", bytes.NewReader(body))
+		require.NoError(t, err)
+		return handler.UpdateScore(httptest.NewRecorder(), asService(req))
+	}
+	score := func(t *testing.T, videoID string) float64 {
+		score, err := mr.ZScore("rankings:global", videoID)
+		require.NoError(t, err)
+		return score
+	}
+
+	t.Run("set duration", func(t *testing.T) {
+		assert.ErrorIs(t, setDuration(t, "video1", 0), ErrorInvalidDuration)
+		assert.ErrorIs(t, setDuration(t, "missing", 100), ErrorVideoNotFound)
+		require.NoError(t, setDuration(t, "video1", 100))
+		assert.Equal(t, "100", mr.HGet("video:video1", "duration"))
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		require.NoError(t, watch(t, "user1", "video1", 50))
+		assert.Equal(t, 1.0, score(t, "video1"))
+	})
+
+	t.Run("rewatch", func(t *testing.T) {
+		require.NoError(t, watch(t, "user1", "video1", 100))
+		assert.Equal(t, 2.0, score(t, "video1"))
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		require.NoError(t, watch(t, "user2", "video1", 110))
+		assert.Equal(t, 4.0, score(t, "video1"))
+
+		assert.ErrorIs(t, watch(t, "user2", "video1", 111), ErrorInvalidWatchTime)
+		assert.ErrorIs(t, watch(t, "user2", "video1", 1_000_000_000), ErrorInvalidWatchTime)
+		assert.ErrorIs(t, watch(t, "user2", "video1", -1), ErrorInvalidWatchTime)
+		assert.Equal(t, 4.0, score(t, "video1"))
+	})
+
+	t.Run("missing watch time", func(t *testing.T) {
+		mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator1", "score", "0")
+		require.NoError(t, watch(t, "user1", "video3", 0))
+		assert.Equal(t, 2.0, score(t, "video3"))
+
+		// watches logged before watch times were reported rebuild as complete
+		assert.Equal(t, 1.0, Interaction{Type: InteractionWatch}.watchFactor())
+	})
+
+	t.Run("unknown duration", func(t *testing.T) {
+		require.NoError(t, watch(t, "user1", "video2", 1_000_000_000))
+		assert.Equal(t, 2.0, score(t, "video2"))
+		// the stats count no more than the default duration
+		assert.Equal(t, "60", mr.HGet("video:video2:stats", "watch_seconds"))
+	})
+
+	t.Run("rewatch window", func(t *testing.T) {
+		mr.FastForward(time.Hour)
+		require.NoError(t, watch(t, "user1", "video1", 100))
+		assert.Equal(t, 6.0, score(t, "video1"))
+	})
+
+	t.Run("held watches are no rewatches", func(t *testing.T) {
+		handler.fraud = fraud.NewDetector(handler.redis, time.Minute, []fraud.Rule{{
+			Name:   "review_watches",
+			Match:  func(fraud.Signals) bool { return true },
+			Action: fraud.ActionDelay,
+		}})
+		require.NoError(t, watch(t, "user3", "video2", 60))
+		handler.fraud = nil
+		require.NoError(t, watch(t, "user3", "video2", 60))
+		assert.Equal(t, 4.0, score(t, "video2"))
+	})
+
+	t.Run("rebuild", func(t *testing.T) {
+		result, err := RebuildRankings(context.Background(), handler.redis, handler.events, false)
+		require.NoError(t, err)
+		assert.Equal(t, 7, result.Interactions)
+		assert.Equal(t, 6.0, score(t, "video1"))
+		assert.Equal(t, 4.0, score(t, "video2"))
+		assert.Equal(t, 2.0, score(t, "video3"))
+	})
+}
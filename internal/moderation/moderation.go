@@ -0,0 +1,441 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Moderation state keys
+const (
+	ReviewKey    = "moderation:review"    // videos pulled from public boards pending review
+	TakedownKey  = "moderation:takedown"  // videos removed from every board, scores are kept
+	ShadowbanKey = "moderation:shadowban" // creators whose videos are left out of every board
+	PinsKey      = "moderation:pins"      // videos pinned on the global board, by video ID
+	// HiddenKey holds the videos excluded from public boards: pending review, taken down or
+	// published by a shadowbanned creator. It is kept up to date by the functions below.
+	HiddenKey = "moderation:hidden"
+)
+
+func reportersKey(videoID string) string {
+	return fmt.Sprintf("video:%s:reporters", videoID)
+}
+
+// Report records a user's report of a video, each user counting once. It returns true
+// when the report brought the video to threshold reporters and put it under review.
+func Report(ctx context.Context, rdb *redis.Client, videoID, userID string, threshold int64) (bool, error) {
+	pipe := rdb.TxPipeline()
+	pipe.SAdd(ctx, reportersKey(videoID), userID)
+	countCmd := pipe.SCard(ctx, reportersKey(videoID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if threshold <= 0 || countCmd.Val() < threshold {
+		return false, nil
+	}
+	added, err := rdb.SAdd(ctx, ReviewKey, videoID).Result()
+	if err != nil {
+		return false, err
+	}
+	return added == 1, refreshHidden(ctx, rdb, videoID)
+}
+
+// Reports returns the number of users who reported a video
+func Reports(ctx context.Context, rdb *redis.Client, videoID string) (int64, error) {
+	return rdb.SCard(ctx, reportersKey(videoID)).Result()
+}
+
+// PendingReview returns the videos under review
+func PendingReview(ctx context.Context, rdb *redis.Client) ([]string, error) {
+	return rdb.SMembers(ctx, ReviewKey).Result()
+}
+
+// ClearReview puts a reviewed video back on the public boards and resets its reports
+func ClearReview(ctx context.Context, rdb *redis.Client, videoID string) (bool, error) {
+	pipe := rdb.TxPipeline()
+	removedCmd := pipe.SRem(ctx, ReviewKey, videoID)
+	pipe.Del(ctx, reportersKey(videoID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return removedCmd.Val() == 1, refreshHidden(ctx, rdb, videoID)
+}
+
+// hide re-derives whether a video belongs in HiddenKey from the review, takedown and
+// shadowban state. KEYS are ReviewKey, TakedownKey, ShadowbanKey and HiddenKey, ARGV the
+// video ID and its creator ID.
+var hide = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 1
+	or redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 1
+	or (ARGV[2] ~= '' and redis.call('SISMEMBER', KEYS[3], ARGV[2]) == 1) then
+	return redis.call('SADD', KEYS[4], ARGV[1])
+end
+return redis.call('SREM', KEYS[4], ARGV[1])
+`)
+
+func hideKeys() []string {
+	return []string{ReviewKey, TakedownKey, ShadowbanKey, HiddenKey}
+}
+
+// refreshHidden updates HiddenKey for a video after its review or takedown changed
+func refreshHidden(ctx context.Context, rdb *redis.Client, videoID string) error {
+	creatorID, err := rdb.HGet(ctx, fmt.Sprintf("video:%s", videoID), "creator_id").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return hide.Run(ctx, rdb, hideKeys(), videoID, creatorID).Err()
+}
+
+// Published hides a video entering the boards when its creator is shadowbanned
+func Published(ctx context.Context, rdb *redis.Client, videoID, creatorID string) error {
+	return hide.Run(ctx, rdb, hideKeys(), videoID, creatorID).Err()
+}
+
+// HiddenAmong returns the videos of videoIDs that are excluded from public boards
+func HiddenAmong(ctx context.Context, rdb *redis.Client, videoIDs []string) (map[string]struct{}, error) {
+	hidden := make(map[string]struct{})
+	if len(videoIDs) == 0 {
+		return hidden, nil
+	}
+	members := make([]any, len(videoIDs))
+	for i, videoID := range videoIDs {
+		members[i] = videoID
+	}
+	found, err := rdb.SMIsMember(ctx, HiddenKey, members...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, ok := range found {
+		if ok {
+			hidden[videoIDs[i]] = struct{}{}
+		}
+	}
+	return hidden, nil
+}
+
+// RebuildHidden recomputes HiddenKey from the videos pending review, taken down or
+// published by a shadowbanned creator
+func RebuildHidden(ctx context.Context, rdb *redis.Client) error {
+	pipe := rdb.Pipeline()
+	videosCmd := pipe.SUnion(ctx, ReviewKey, TakedownKey)
+	creatorsCmd := pipe.SMembers(ctx, ShadowbanKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	hidden := videosCmd.Val()
+	if len(creatorsCmd.Val()) > 0 {
+		pipe = rdb.Pipeline()
+		cmds := make([]*redis.StringSliceCmd, len(creatorsCmd.Val()))
+		for i, creatorID := range creatorsCmd.Val() {
+			cmds[i] = pipe.ZRange(ctx, fmt.Sprintf("creator:%s:videos", creatorID), 0, -1)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			hidden = append(hidden, cmd.Val()...)
+		}
+	}
+	_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, HiddenKey)
+		if len(hidden) > 0 {
+			pipe.SAdd(ctx, HiddenKey, hidden)
+		}
+		return nil
+	})
+	return err
+}
+
+// SetTakedown takes a video down or restores it, returning false when it was already in that state
+func SetTakedown(ctx context.Context, rdb *redis.Client, videoID string, down bool) (bool, error) {
+	changed, err := setMember(ctx, rdb, TakedownKey, videoID, down)
+	if err != nil {
+		return false, err
+	}
+	return changed, refreshHidden(ctx, rdb, videoID)
+}
+
+// IsTakenDown reports whether a video was taken down
+func IsTakenDown(ctx context.Context, rdb *redis.Client, videoID string) (bool, error) {
+	return rdb.SIsMember(ctx, TakedownKey, videoID).Result()
+}
+
+// SetShadowban shadowbans a creator or lifts the ban, returning false when it was already in that state
+func SetShadowban(ctx context.Context, rdb *redis.Client, creatorID string, banned bool) (bool, error) {
+	changed, err := setMember(ctx, rdb, ShadowbanKey, creatorID, banned)
+	if err != nil {
+		return false, err
+	}
+	videoIDs, err := rdb.ZRange(ctx, fmt.Sprintf("creator:%s:videos", creatorID), 0, -1).Result()
+	if err != nil {
+		return false, err
+	}
+	pipe := rdb.Pipeline()
+	for _, videoID := range videoIDs {
+		hide.Eval(ctx, pipe, hideKeys(), videoID, creatorID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+func setMember(ctx context.Context, rdb *redis.Client, key, member string, present bool) (bool, error) {
+	var changed int64
+	var err error
+	if present {
+		changed, err = rdb.SAdd(ctx, key, member).Result()
+	} else {
+		changed, err = rdb.SRem(ctx, key, member).Result()
+	}
+	return changed == 1, err
+}
+
+// State lists the moderated videos and creators
+type State struct {
+	Review     []string `json:"review"`
+	Takedowns  []string `json:"takedowns"`
+	Shadowbans []string `json:"shadowbans"`
+	Pins       []Pin    `json:"pins"`
+}
+
+// GetState returns the current moderation state
+func GetState(ctx context.Context, rdb *redis.Client, now time.Time) (State, error) {
+	pipe := rdb.Pipeline()
+	reviewCmd := pipe.SMembers(ctx, ReviewKey)
+	takedownCmd := pipe.SMembers(ctx, TakedownKey)
+	shadowbanCmd := pipe.SMembers(ctx, ShadowbanKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return State{}, err
+	}
+	pins, err := Pins(ctx, rdb, now)
+	if err != nil {
+		return State{}, err
+	}
+	state := State{
+		Review:     reviewCmd.Val(),
+		Takedowns:  takedownCmd.Val(),
+		Shadowbans: shadowbanCmd.Val(),
+		Pins:       pins,
+	}
+	for _, members := range [][]string{state.Review, state.Takedowns, state.Shadowbans} {
+		sort.Strings(members)
+	}
+	return state, nil
+}
+
+// visibleRange walks the board in KEYS[1] best first, skipping the videos in HiddenKey
+// (KEYS[2]) and the excluded ones, and returns the member, score and organic rank of the
+// visible entries from ARGV[1] to ARGV[1]+ARGV[2]. The organic rank counts the excluded
+// videos but not the hidden ones. Entries are read ARGV[3] at a time and ARGV[4:] are
+// the excluded videos.
+var visibleRange = redis.NewScript(`
+local offset = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local batch = tonumber(ARGV[3])
+local excluded = {}
+for i = 4, #ARGV do
+	excluded[ARGV[i]] = true
+end
+local page = {}
+local start = 0
+local rank = 0
+while #page < 3 * limit do
+	local entries = redis.call('ZREVRANGE', KEYS[1], start, start + batch - 1, 'WITHSCORES')
+	for i = 1, #entries, 2 do
+		if #page == 3 * limit then
+			break
+		end
+		if redis.call('SISMEMBER', KEYS[2], entries[i]) == 0 then
+			rank = rank + 1
+			if not excluded[entries[i]] then
+				if offset > 0 then
+					offset = offset - 1
+				else
+					table.insert(page, entries[i])
+					table.insert(page, entries[i + 1])
+					table.insert(page, tostring(rank))
+				end
+			end
+		end
+	end
+	if #entries < 2 * batch then
+		break
+	end
+	start = start + batch
+end
+return page
+`)
+
+// MaxDepth bounds offset+limit of the pages served from Range. The filter runs in one
+// script that blocks Redis while it walks the board, so clients must not page deeper.
+const MaxDepth = 1000
+
+// Range returns the entries of a board, best first, from offset to offset+limit as if
+// the hidden videos and the excluded ones were not part of it. It over-fetches the
+// board from the top and filters it in Redis, so the cost grows with offset+limit and
+// the hidden videos met on the way, not with the size of HiddenKey.
+func Range(ctx context.Context, rdb *redis.Client, key string, excluded []string, offset, limit int64) ([]redis.Z, error) {
+	entries, _, err := rankedRange(ctx, rdb, key, excluded, offset, limit)
+	return entries, err
+}
+
+// rankedRange is Range also returning the 1-based rank of each entry on the board without
+// the hidden videos, the excluded videos keeping their places
+func rankedRange(ctx context.Context, rdb *redis.Client, key string, excluded []string, offset, limit int64) ([]redis.Z, []int64, error) {
+	if limit <= 0 {
+		return nil, nil, nil
+	}
+	args := []any{offset, limit, offset + limit}
+	for _, videoID := range excluded {
+		args = append(args, videoID)
+	}
+	values, err := visibleRange.Run(ctx, rdb, []string{key, HiddenKey}, args...).StringSlice()
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := make([]redis.Z, 0, len(values)/3)
+	ranks := make([]int64, 0, len(values)/3)
+	for i := 0; i+2 < len(values); i += 3 {
+		score, err := strconv.ParseFloat(values[i+1], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+		rank, err := strconv.ParseInt(values[i+2], 10, 64)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, redis.Z{Score: score, Member: values[i]})
+		ranks = append(ranks, rank)
+	}
+	return entries, ranks, nil
+}
+
+// Pin places a video at a fixed 1-based position of the global board until it expires
+type Pin struct {
+	VideoID   string `json:"video_id"`
+	Position  int64  `json:"position"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// SetPin pins a video, replacing its previous pin
+func SetPin(ctx context.Context, rdb *redis.Client, pin Pin) error {
+	return rdb.HSet(ctx, PinsKey, pin.VideoID, fmt.Sprintf("%d:%d", pin.Position, pin.ExpiresAt)).Err()
+}
+
+// RemovePin unpins a video, returning false when it was not pinned
+func RemovePin(ctx context.Context, rdb *redis.Client, videoID string) (bool, error) {
+	removed, err := rdb.HDel(ctx, PinsKey, videoID).Result()
+	return removed == 1, err
+}
+
+// Pins returns the pins active at now ordered by position, dropping the expired ones
+func Pins(ctx context.Context, rdb *redis.Client, now time.Time) ([]Pin, error) {
+	fields, err := rdb.HGetAll(ctx, PinsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	var pins []Pin
+	var expired []string
+	for videoID, value := range fields {
+		position, expiresAt, _ := strings.Cut(value, ":")
+		pin := Pin{VideoID: videoID}
+		pin.Position, _ = strconv.ParseInt(position, 10, 64)
+		pin.ExpiresAt, _ = strconv.ParseInt(expiresAt, 10, 64)
+		if pin.ExpiresAt <= now.Unix() {
+			expired = append(expired, videoID)
+			continue
+		}
+		pins = append(pins, pin)
+	}
+	if len(expired) > 0 {
+		if err := rdb.HDel(ctx, PinsKey, expired...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(pins, func(i, j int) bool {
+		if pins[i].Position != pins[j].Position {
+			return pins[i].Position < pins[j].Position
+		}
+		return pins[i].VideoID < pins[j].VideoID
+	})
+	return pins, nil
+}
+
+// PinnedRange returns a page of a board like Range, with the pinned videos at their
+// positions and the other videos flowing around them. Pins sharing a position take the
+// following ones and hidden pins are left out. The returned map holds the organic rank of
+// the videos of the page that are not pinned, their rank on the board without pins like
+// Range with no exclusion would give, so pins do not shift it.
+func PinnedRange(ctx context.Context, rdb *redis.Client, key string, pins []Pin, offset, limit int64) ([]redis.Z, map[string]int64, error) {
+	pinIDs := make([]string, len(pins))
+	for i, pin := range pins {
+		pinIDs[i] = pin.VideoID
+	}
+	hiddenPins, err := HiddenAmong(ctx, rdb, pinIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 0-based index of each visible pin
+	byIndex := make(map[int64]string, len(pins))
+	var next int64
+	for _, pin := range pins {
+		if _, ok := hiddenPins[pin.VideoID]; ok {
+			continue
+		}
+		index := max(pin.Position-1, next)
+		byIndex[index] = pin.VideoID
+		next = index + 1
+	}
+
+	pinnedBefore := int64(0)
+	for index := range byIndex {
+		if index < offset {
+			pinnedBefore++
+		}
+	}
+	organic, organicRanks, err := rankedRange(ctx, rdb, key, pinIDs, offset-pinnedBefore, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pinnedIDs := make([]string, 0, len(byIndex))
+	for index, videoID := range byIndex {
+		if index >= offset && index < offset+limit {
+			pinnedIDs = append(pinnedIDs, videoID)
+		}
+	}
+	pinnedScores := make(map[string]float64, len(pinnedIDs))
+	if len(pinnedIDs) > 0 {
+		scores, err := rdb.ZMScore(ctx, key, pinnedIDs...).Result()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, videoID := range pinnedIDs {
+			pinnedScores[videoID] = scores[i]
+		}
+	}
+
+	page := make([]redis.Z, 0, limit)
+	ranks := make(map[string]int64, len(organic))
+	for index := offset; index < offset+limit; index++ {
+		if videoID, ok := byIndex[index]; ok {
+			page = append(page, redis.Z{Score: pinnedScores[videoID], Member: videoID})
+			continue
+		}
+		// past the end of the board only pins are left
+		if len(organic) == 0 {
+			continue
+		}
+		page = append(page, organic[0])
+		ranks[organic[0].Member.(string)] = organicRanks[0]
+		organic, organicRanks = organic[1:], organicRanks[1:]
+	}
+	return page, ranks, nil
+}
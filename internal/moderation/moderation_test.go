@@ -0,0 +1,204 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTest(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+	return client, mr
+}
+
+func TestRange(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	for i, videoID := range []string{"video1", "video2", "video3", "video4", "video5", "video6"} {
+		mr.ZAdd("rankings:global", float64(60-10*i), videoID)
+	}
+	members := func(entries []redis.Z) []string {
+		var videoIDs []string
+		for _, entry := range entries {
+			videoIDs = append(videoIDs, entry.Member.(string))
+		}
+		return videoIDs
+	}
+
+	tests := []struct {
+		name          string
+		hidden        []string
+		excluded      []string
+		offset, limit int64
+		expected      []string
+	}{
+		{name: "nothing hidden", offset: 1, limit: 2, expected: []string{"video2", "video3"}},
+		{name: "hidden in page", hidden: []string{"video2"}, offset: 0, limit: 2, expected: []string{"video1", "video3"}},
+		{name: "hidden before page", hidden: []string{"video1", "video3"}, offset: 1, limit: 2, expected: []string{"video4", "video5"}},
+		{name: "unranked hidden", hidden: []string{"missing", "video6"}, offset: 4, limit: 2, expected: []string{"video5"}},
+		{name: "excluded", hidden: []string{"video1"}, excluded: []string{"video2"}, offset: 0, limit: 2, expected: []string{"video3", "video4"}},
+		{name: "past the end", hidden: []string{"video1"}, offset: 5, limit: 2, expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr.Del(HiddenKey)
+			for _, videoID := range tt.hidden {
+				mr.SAdd(HiddenKey, videoID)
+			}
+			entries, err := Range(ctx, client, "rankings:global", tt.excluded, tt.offset, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, members(entries))
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	pulled, err := Report(ctx, client, "video1", "user1", 2)
+	require.NoError(t, err)
+	assert.False(t, pulled)
+
+	// the same user counts once
+	pulled, err = Report(ctx, client, "video1", "user1", 2)
+	require.NoError(t, err)
+	assert.False(t, pulled)
+
+	pulled, err = Report(ctx, client, "video1", "user2", 2)
+	require.NoError(t, err)
+	assert.True(t, pulled)
+
+	hidden, err := client.SMembers(ctx, HiddenKey).Result()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video1"}, hidden)
+
+	cleared, err := ClearReview(ctx, client, "video1")
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	assert.False(t, mr.Exists(HiddenKey))
+	reports, err := Reports(ctx, client, "video1")
+	require.NoError(t, err)
+	assert.Zero(t, reports)
+}
+
+func TestHidden(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	mr.ZAdd("creator:creator1:videos", 1, "video1")
+	mr.ZAdd("creator:creator1:videos", 2, "video2")
+	mr.SAdd(ReviewKey, "video3")
+	mr.HSet("video:video2", "creator_id", "creator1")
+	hidden := func(t *testing.T) []string {
+		hidden, err := client.SMembers(ctx, HiddenKey).Result()
+		require.NoError(t, err)
+		return hidden
+	}
+
+	// the hidden set is rebuilt from the moderation state
+	require.NoError(t, RebuildHidden(ctx, client))
+	assert.Equal(t, []string{"video3"}, hidden(t))
+
+	changed, err := SetTakedown(ctx, client, "video4", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = SetShadowban(ctx, client, "creator1", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = SetShadowban(ctx, client, "creator1", true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	assert.ElementsMatch(t, []string{"video1", "video2", "video3", "video4"}, hidden(t))
+
+	// videos published by a shadowbanned creator are hidden as they enter the boards
+	require.NoError(t, Published(ctx, client, "video5", "creator1"))
+	require.NoError(t, Published(ctx, client, "video6", "creator2"))
+	assert.ElementsMatch(t, []string{"video1", "video2", "video3", "video4", "video5"}, hidden(t))
+
+	// a video taken down stays hidden when the ban is lifted
+	_, err = SetTakedown(ctx, client, "video2", true)
+	require.NoError(t, err)
+	_, err = SetShadowban(ctx, client, "creator1", false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"video2", "video3", "video4", "video5"}, hidden(t))
+
+	require.NoError(t, RebuildHidden(ctx, client))
+	assert.ElementsMatch(t, []string{"video2", "video3", "video4"}, hidden(t))
+
+	found, err := HiddenAmong(ctx, client, []string{"video1", "video2", "video6"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"video2": {}}, found)
+}
+
+func TestPinnedRange(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	now := time.Unix(1690000000, 0)
+	for i, videoID := range []string{"video1", "video2", "video3", "video4", "video5"} {
+		mr.ZAdd("rankings:global", float64(50-10*i), videoID)
+	}
+	require.NoError(t, SetPin(ctx, client, Pin{VideoID: "video5", Position: 1, ExpiresAt: now.Add(time.Hour).Unix()}))
+	require.NoError(t, SetPin(ctx, client, Pin{VideoID: "video4", Position: 1, ExpiresAt: now.Add(time.Hour).Unix()}))
+	require.NoError(t, SetPin(ctx, client, Pin{VideoID: "video3", Position: 2, ExpiresAt: now.Add(-time.Hour).Unix()}))
+
+	pins, err := Pins(ctx, client, now)
+	require.NoError(t, err)
+	// pins sharing a position are ordered by video ID, expired pins are dropped
+	assert.Equal(t, []Pin{
+		{VideoID: "video4", Position: 1, ExpiresAt: now.Add(time.Hour).Unix()},
+		{VideoID: "video5", Position: 1, ExpiresAt: now.Add(time.Hour).Unix()},
+	}, pins)
+	keys, err := mr.HKeys(PinsKey)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video4", "video5"}, keys)
+
+	members := func(entries []redis.Z) []string {
+		var videoIDs []string
+		for _, entry := range entries {
+			videoIDs = append(videoIDs, entry.Member.(string))
+		}
+		return videoIDs
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		entries, ranks, err := PinnedRange(ctx, client, "rankings:global", pins, 0, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video4", "video5", "video1"}, members(entries))
+		assert.Equal(t, 10.0, entries[1].Score)
+		assert.Equal(t, map[string]int64{"video1": 1}, ranks)
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		entries, ranks, err := PinnedRange(ctx, client, "rankings:global", pins, 3, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video2", "video3"}, members(entries))
+		// the pinned videos keep their organic places
+		assert.Equal(t, map[string]int64{"video2": 2, "video3": 3}, ranks)
+	})
+
+	t.Run("hidden pin", func(t *testing.T) {
+		mr.SAdd(HiddenKey, "video4", "video1")
+		entries, ranks, err := PinnedRange(ctx, client, "rankings:global", pins, 0, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video5", "video2", "video3"}, members(entries))
+		assert.Equal(t, map[string]int64{"video2": 1, "video3": 2}, ranks)
+	})
+}
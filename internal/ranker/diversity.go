@@ -0,0 +1,133 @@
+package ranker
+
+import (
+	"context"
+	"math"
+)
+
+// Spread limits how many candidates sharing a key appear in any window of consecutive
+// results. Candidates that would exceed the limit are pushed down to the first position
+// where they fit; when no remaining candidate fits, the best one is placed anyway.
+// Max and Window can be overridden with the <name>_max and <name>_window params.
+type Spread struct {
+	name   string
+	keys   func(candidate *Candidate) []string
+	Max    int
+	Window int
+}
+
+// CreatorSpread allows at most maxPerWindow videos of a creator in any window of results
+func CreatorSpread(maxPerWindow, window int) Spread {
+	return Spread{
+		name:   "creator",
+		keys:   func(candidate *Candidate) []string { return []string{candidate.CreatorID} },
+		Max:    maxPerWindow,
+		Window: window,
+	}
+}
+
+// TopicSpread allows at most maxPerWindow videos of a category, or sharing a tag, in any window of results
+func TopicSpread(maxPerWindow, window int) Spread {
+	return Spread{
+		name: "topic",
+		keys: func(candidate *Candidate) []string {
+			keys := make([]string, 0, len(candidate.Tags)+1)
+			if candidate.Category != "" {
+				keys = append(keys, "category:"+candidate.Category)
+			}
+			for _, tag := range candidate.Tags {
+				keys = append(keys, "tag:"+tag)
+			}
+			return keys
+		},
+		Max:    maxPerWindow,
+		Window: window,
+	}
+}
+
+func (s Spread) Name() string { return s.name }
+
+func (s Spread) Params() []string { return []string{s.name + "_max", s.name + "_window"} }
+
+func (s Spread) Rerank(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error) {
+	limit := int(req.Param(s.name+"_max", float64(s.Max)))
+	window := int(req.Param(s.name+"_window", float64(s.Window)))
+	if limit <= 0 || window <= 1 {
+		return candidates, nil
+	}
+
+	remaining := append([]*Candidate(nil), candidates...)
+	reranked := make([]*Candidate, 0, len(candidates))
+	for len(remaining) > 0 {
+		// counts of the keys in the window ending at the next position
+		counts := make(map[string]int)
+		for _, placed := range reranked[max(len(reranked)-window+1, 0):] {
+			for _, key := range s.keys(placed) {
+				counts[key]++
+			}
+		}
+
+		next := 0
+		for i, candidate := range remaining {
+			if s.fits(candidate, counts, limit) {
+				next = i
+				break
+			}
+		}
+		reranked = append(reranked, remaining[next])
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return reranked, nil
+}
+
+func (s Spread) fits(candidate *Candidate, counts map[string]int, limit int) bool {
+	for _, key := range s.keys(candidate) {
+		if key != "" && counts[key] >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+// Interleave mixes followed-creator and other candidates so that Ratio of every prefix
+// of the results comes from followed creators, keeping the order within each group.
+// Ratio can be overridden with the interleave_ratio param, 0 disables interleaving.
+type Interleave struct {
+	Ratio float64
+}
+
+func (s Interleave) Name() string { return "interleave" }
+
+func (s Interleave) Params() []string { return []string{"interleave_ratio"} }
+
+func (s Interleave) Rerank(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error) {
+	ratio := req.Param("interleave_ratio", s.Ratio)
+	if ratio <= 0 || ratio > 1 {
+		return candidates, nil
+	}
+
+	var followed, others []*Candidate
+	for _, candidate := range candidates {
+		if contains(candidate.Sources, FollowedCreators{}.Name()) {
+			followed = append(followed, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+
+	reranked := make([]*Candidate, 0, len(candidates))
+	taken := 0
+	for len(followed) > 0 || len(others) > 0 {
+		position := len(reranked) + 1
+		wantFollowed := float64(taken) < math.Round(ratio*float64(position))
+		if len(followed) > 0 && (wantFollowed || len(others) == 0) {
+			reranked = append(reranked, followed[0])
+			followed = followed[1:]
+			taken++
+		} else {
+			reranked = append(reranked, others[0])
+			others = others[1:]
+		}
+	}
+	return reranked, nil
+}
@@ -0,0 +1,88 @@
+package ranker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiversity(t *testing.T) {
+	ctx := context.Background()
+	candidate := func(videoID, creatorID, category string, followed bool) *Candidate {
+		sources := []string{"global_top"}
+		if followed {
+			sources = []string{"followed_creators"}
+		}
+		return &Candidate{VideoID: videoID, CreatorID: creatorID, Category: category, Sources: sources}
+	}
+	feed := func() []*Candidate {
+		return []*Candidate{
+			candidate("a1", "a", "music", true),
+			candidate("a2", "a", "music", true),
+			candidate("a3", "a", "music", true),
+			candidate("a4", "a", "sport", true),
+			candidate("b1", "b", "music", false),
+			candidate("c1", "c", "sport", false),
+		}
+	}
+	ids := func(candidates []*Candidate) []string {
+		var videoIDs []string
+		for _, candidate := range candidates {
+			videoIDs = append(videoIDs, candidate.VideoID)
+		}
+		return videoIDs
+	}
+
+	t.Run("creator spread", func(t *testing.T) {
+		reranked, err := CreatorSpread(2, 3).Rerank(ctx, Request{}, nil, feed())
+		require.NoError(t, err)
+		// a3 waits for a window without two videos of a
+		assert.Equal(t, []string{"a1", "a2", "b1", "a3", "a4", "c1"}, ids(reranked))
+	})
+
+	t.Run("creator spread params", func(t *testing.T) {
+		req := Request{Params: map[string]float64{"creator_max": 1, "creator_window": 2}}
+		reranked, err := CreatorSpread(2, 3).Rerank(ctx, req, nil, feed())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a1", "b1", "a2", "c1", "a3", "a4"}, ids(reranked))
+	})
+
+	t.Run("topic spread", func(t *testing.T) {
+		candidates := feed()
+		candidates[3].Tags = []string{"music"}
+		reranked, err := TopicSpread(1, 2).Rerank(ctx, Request{}, nil, candidates)
+		require.NoError(t, err)
+		// a music tag does not count as the music category, b1 has nowhere to fit
+		assert.Equal(t, []string{"a1", "a4", "a2", "c1", "a3", "b1"}, ids(reranked))
+	})
+
+	t.Run("interleave", func(t *testing.T) {
+		reranked, err := Interleave{Ratio: 0.5}.Rerank(ctx, Request{}, nil, feed())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a1", "b1", "a2", "c1", "a3", "a4"}, ids(reranked))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		for _, reranker := range []Reranker{CreatorSpread(0, 3), TopicSpread(1, 0), Interleave{}} {
+			reranked, err := reranker.Rerank(ctx, Request{}, nil, feed())
+			require.NoError(t, err)
+			assert.Equal(t, ids(feed()), ids(reranked), reranker.Name())
+		}
+	})
+
+	t.Run("pipeline", func(t *testing.T) {
+		client, mr := setupTest(t)
+		defer mr.Close()
+		mr.HSet("video:video2", "category", "music", "tags", "live,piano")
+		mr.HSet("video:video3", "category", "music")
+
+		strategy := NewPipeline(client, []CandidateGenerator{GlobalTop{Redis: client, TopM: 10}}, nil).
+			WithRerankers(TopicSpread(1, 2))
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video2", "video1", "video3"}, ids(candidates))
+		assert.Equal(t, []string{"live", "piano"}, candidates[0].Tags)
+	})
+}
@@ -0,0 +1,353 @@
+package ranker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Request is the input of a ranking strategy
+type Request struct {
+	UserID string
+	Limit  int
+	// Params override stage weights by name, e.g. follow_boost
+	Params map[string]float64
+}
+
+// Param returns the named parameter or fallback when it is not set
+func (r Request) Param(name string, fallback float64) float64 {
+	if v, ok := r.Params[name]; ok {
+		return v
+	}
+	return fallback
+}
+
+// maxCount caps the count params, such as followed_top_k, a generator reads per board
+const maxCount = 1000
+
+// Count returns the named count parameter, or fallback when it is not set, clamped to
+// [1, maxCount] so that a zero or negative count never reads a whole board
+func (r Request) Count(name string, fallback int64) int64 {
+	count := r.Param(name, float64(fallback))
+	if !(count >= 1) {
+		return 1
+	}
+	return int64(min(count, maxCount))
+}
+
+// UserProfile is the user state shared by the stages of a pipeline
+type UserProfile struct {
+	Follows map[string]struct{}
+	// Seen maps the videos of the seen history to their last interaction time
+	Seen map[string]time.Time
+	// Hidden and NotInterested are the videos and creators the user asked not to see
+	Hidden        map[string]struct{}
+	NotInterested map[string]struct{}
+}
+
+// Candidate is a video considered for a ranking
+type Candidate struct {
+	VideoID   string
+	CreatorID string
+	// Category and Tags come from the category and comma-separated tags fields of the video hash
+	Category string
+	Tags     []string
+	// BaseScore is the global score, Score the score adjusted by the scorers
+	BaseScore float64
+	Score     float64
+	// Sources lists the generators that produced the candidate
+	Sources []string
+	// Boosts lists the adjustments applied by the scorers, in order
+	Boosts []Boost
+}
+
+// Boost is a score adjustment applied by a scorer
+type Boost struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// Boost adds value to the score and records it under name
+func (c *Candidate) Boost(name string, value float64) {
+	c.Score += value
+	c.Boosts = append(c.Boosts, Boost{Name: name, Value: value})
+}
+
+// CandidateGenerator produces the video IDs a pipeline considers
+type CandidateGenerator interface {
+	Name() string
+	Generate(ctx context.Context, req Request, user *UserProfile) ([]string, error)
+}
+
+// Scorer adjusts the scores of the candidates
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) error
+}
+
+// Filter removes candidates before they are scored
+type Filter interface {
+	Name() string
+	Filter(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error)
+}
+
+// Reranker reorders the scored candidates, e.g. to diversify the results
+type Reranker interface {
+	Name() string
+	Rerank(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error)
+}
+
+// Ranker returns candidates ordered best first
+type Ranker interface {
+	Rank(ctx context.Context, req Request) ([]*Candidate, error)
+}
+
+// Tunable is implemented by the stages and rankers whose weights can be overridden
+// with Request.Params, Params lists the names they read
+type Tunable interface {
+	Params() []string
+}
+
+// Pipeline is a Ranker composed of candidate generators followed by filters, scorers
+// and rerankers
+type Pipeline struct {
+	redis      *redis.Client
+	generators []CandidateGenerator
+	filters    []Filter
+	scorers    []Scorer
+	rerankers  []Reranker
+}
+
+func NewPipeline(redis *redis.Client, generators []CandidateGenerator, scorers []Scorer) *Pipeline {
+	return &Pipeline{
+		redis:      redis,
+		generators: generators,
+		scorers:    scorers,
+	}
+}
+
+// WithFilters adds filters run on the candidates before scoring
+func (p *Pipeline) WithFilters(filters ...Filter) *Pipeline {
+	p.filters = append(p.filters, filters...)
+	return p
+}
+
+// WithRerankers adds rerankers run in order on the candidates sorted by score
+func (p *Pipeline) WithRerankers(rerankers ...Reranker) *Pipeline {
+	p.rerankers = append(p.rerankers, rerankers...)
+	return p
+}
+
+// Params lists the params read by the stages of the pipeline
+func (p *Pipeline) Params() []string {
+	var stages []any
+	for _, generator := range p.generators {
+		stages = append(stages, generator)
+	}
+	for _, filter := range p.filters {
+		stages = append(stages, filter)
+	}
+	for _, scorer := range p.scorers {
+		stages = append(stages, scorer)
+	}
+	for _, reranker := range p.rerankers {
+		stages = append(stages, reranker)
+	}
+	var params []string
+	for _, stage := range stages {
+		if tunable, ok := stage.(Tunable); ok {
+			for _, param := range tunable.Params() {
+				if !contains(params, param) {
+					params = append(params, param)
+				}
+			}
+		}
+	}
+	return params
+}
+
+func (p *Pipeline) Rank(ctx context.Context, req Request) ([]*Candidate, error) {
+	user, err := loadUserProfile(ctx, p.redis, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := p.generate(ctx, req, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, filter := range p.filters {
+		if candidates, err = filter.Filter(ctx, req, user, candidates); err != nil {
+			return nil, fmt.Errorf("filter %s: %w", filter.Name(), err)
+		}
+	}
+	for _, scorer := range p.scorers {
+		if err := scorer.Score(ctx, req, user, candidates); err != nil {
+			return nil, fmt.Errorf("scorer %s: %w", scorer.Name(), err)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].VideoID < candidates[j].VideoID
+	})
+	for _, reranker := range p.rerankers {
+		if candidates, err = reranker.Rerank(ctx, req, user, candidates); err != nil {
+			return nil, fmt.Errorf("reranker %s: %w", reranker.Name(), err)
+		}
+	}
+	return candidates, nil
+}
+
+// generate collects the deduplicated candidates with their global score, creator and topics
+func (p *Pipeline) generate(ctx context.Context, req Request, user *UserProfile) ([]*Candidate, error) {
+	var candidates []*Candidate
+	byID := make(map[string]*Candidate)
+	for _, generator := range p.generators {
+		videoIDs, err := generator.Generate(ctx, req, user)
+		if err != nil {
+			return nil, fmt.Errorf("generator %s: %w", generator.Name(), err)
+		}
+		for _, videoID := range videoIDs {
+			candidate, ok := byID[videoID]
+			if !ok {
+				candidate = &Candidate{VideoID: videoID}
+				byID[videoID] = candidate
+				candidates = append(candidates, candidate)
+			}
+			if !contains(candidate.Sources, generator.Name()) {
+				candidate.Sources = append(candidate.Sources, generator.Name())
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	videoIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		videoIDs[i] = candidate.VideoID
+	}
+	pipe := p.redis.Pipeline()
+	scoresCmd := pipe.ZMScore(ctx, "rankings:global", videoIDs...)
+	videoCmds := make([]*redis.SliceCmd, len(candidates))
+	for i, videoID := range videoIDs {
+		videoCmds[i] = pipe.HMGet(ctx, fmt.Sprintf("video:%s", videoID), "creator_id", "category", "tags")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("get candidate data: %w", err)
+	}
+
+	scores := scoresCmd.Val()
+	for i, candidate := range candidates {
+		if i < len(scores) {
+			candidate.BaseScore = scores[i]
+			candidate.Score = scores[i]
+		}
+		fields := videoCmds[i].Val()
+		candidate.CreatorID, _ = fields[0].(string)
+		candidate.Category, _ = fields[1].(string)
+		if tags, _ := fields[2].(string); tags != "" {
+			candidate.Tags = strings.Split(tags, ",")
+		}
+	}
+	return candidates, nil
+}
+
+func loadUserProfile(ctx context.Context, rdb *redis.Client, userID string) (*UserProfile, error) {
+	pipe := rdb.Pipeline()
+	followsCmd := pipe.SMembers(ctx, fmt.Sprintf("user:%s:follows", userID))
+	// the seen history is bounded, it is loaded whole
+	seenCmd := pipe.ZRangeWithScores(ctx, seenKey(userID), 0, -1)
+	hiddenCmd := pipe.SMembers(ctx, fmt.Sprintf("user:%s:hidden", userID))
+	notInterestedCmd := pipe.SMembers(ctx, fmt.Sprintf("user:%s:not_interested", userID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("get user profile: %w", err)
+	}
+
+	seen := make(map[string]time.Time, len(seenCmd.Val()))
+	for _, entry := range seenCmd.Val() {
+		seen[entry.Member.(string)] = time.Unix(int64(entry.Score), 0)
+	}
+	return &UserProfile{
+		Follows:       toSet(followsCmd.Val()),
+		Seen:          seen,
+		Hidden:        toSet(hiddenCmd.Val()),
+		NotInterested: toSet(notInterestedCmd.Val()),
+	}, nil
+}
+
+// Registry holds the ranking strategies selectable by name
+type Registry struct {
+	mu         sync.RWMutex
+	strategies map[string]Ranker
+	fallback   string
+}
+
+// NewRegistry creates a registry whose Get falls back to the named strategy
+func NewRegistry(fallback string) *Registry {
+	return &Registry{
+		strategies: make(map[string]Ranker),
+		fallback:   fallback,
+	}
+}
+
+func (r *Registry) Register(name string, ranker Ranker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strategies[name] = ranker
+}
+
+// Resolve returns the strategy name Get looks up for name
+func (r *Registry) Resolve(name string) string {
+	if name == "" {
+		return r.fallback
+	}
+	return name
+}
+
+// Get returns the named strategy, or the fallback when name is empty
+func (r *Registry) Get(name string) (Ranker, bool) {
+	name = r.Resolve(name)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ranker, ok := r.strategies[name]
+	return ranker, ok
+}
+
+// Params returns the params the named strategy reads, false when it is not registered
+func (r *Registry) Params(name string) ([]string, bool) {
+	ranker, ok := r.Get(name)
+	if !ok {
+		return nil, false
+	}
+	if tunable, ok := ranker.(Tunable); ok {
+		return tunable.Params(), true
+	}
+	return nil, true
+}
+
+func toSet(items []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
+
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
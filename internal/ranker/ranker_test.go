@@ -0,0 +1,132 @@
+package ranker
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTest(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+
+	client := redis.NewClient(&redis.Options{
+		Addr: mr.Addr(),
+	})
+
+	mr.SAdd("user:user1:follows", "creator1")
+	mr.ZAdd("user:user1:seen", 1690000000, "video3")
+
+	mr.HSet("video:video1", "title", "Video One", "creator_id", "creator1", "score", "10")
+	mr.HSet("video:video2", "title", "Video Two", "creator_id", "creator2", "score", "80")
+	mr.HSet("video:video3", "title", "Video Three", "creator_id", "creator2", "score", "60")
+	mr.ZAdd("rankings:global", 10, "video1")
+	mr.ZAdd("rankings:global", 80, "video2")
+	mr.ZAdd("rankings:global", 60, "video3")
+	mr.ZAdd("creator:creator1:videos", 10, "video1")
+	mr.ZAdd("creator:creator2:videos", 80, "video2")
+	mr.ZAdd("creator:creator2:videos", 60, "video3")
+
+	return client, mr
+}
+
+// constantScorer adds a fixed amount to one video
+type constantScorer struct {
+	videoID string
+	boost   float64
+}
+
+func (s constantScorer) Name() string { return "constant" }
+
+func (s constantScorer) Score(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) error {
+	for _, candidate := range candidates {
+		if candidate.VideoID == s.videoID {
+			candidate.Score += s.boost
+		}
+	}
+	return nil
+}
+
+func TestPipeline(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	registry := DefaultRegistry(client, Config{Strategy: StrategyDefault, SeenPolicy: SeenBoost, SeenWeight: 50})
+
+	t.Run("default strategy", func(t *testing.T) {
+		strategy, ok := registry.Get("")
+		require.True(t, ok)
+
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user1", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, candidates, 3)
+
+		// 10 + follow boost, 60 + seen boost, 80
+		assert.Equal(t, "video1", candidates[0].VideoID)
+		assert.Equal(t, 110.0, candidates[0].Score)
+		assert.Equal(t, 10.0, candidates[0].BaseScore)
+		assert.Equal(t, []string{"followed_creators", "global_top"}, candidates[0].Sources)
+		assert.Equal(t, "video3", candidates[1].VideoID)
+		assert.Equal(t, 110.0, candidates[1].Score)
+		assert.Equal(t, "video2", candidates[2].VideoID)
+		assert.Equal(t, []string{"global_top"}, candidates[2].Sources)
+	})
+
+	t.Run("registered strategy", func(t *testing.T) {
+		registry.Register("custom", NewPipeline(client,
+			[]CandidateGenerator{FollowedCreators{Redis: client, TopK: 10}},
+			[]Scorer{constantScorer{videoID: "video1", boost: 1}},
+		))
+		strategy, ok := registry.Get("custom")
+		require.True(t, ok)
+
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user1", Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, candidates, 1)
+		assert.Equal(t, 11.0, candidates[0].Score)
+	})
+
+	t.Run("freshness boost", func(t *testing.T) {
+		now := time.Unix(1690000000, 0)
+		mr.HSet("video:video2", "created_at", strconv.FormatInt(now.Add(-24*time.Hour).Unix(), 10))
+		mr.HSet("video:video3", "created_at", strconv.FormatInt(now.Unix(), 10))
+
+		strategy := NewPipeline(client,
+			[]CandidateGenerator{GlobalTop{Redis: client, TopM: 10}},
+			[]Scorer{FreshnessBoost{Redis: client, Boost: 40, HalfLife: 24 * time.Hour, Now: func() time.Time { return now }}},
+		)
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user1"})
+		require.NoError(t, err)
+		require.Len(t, candidates, 3)
+
+		// 60 + 40 fresh, 80 + 20 a half-life old, 10 without created_at
+		assert.Equal(t, "video2", candidates[0].VideoID)
+		assert.Equal(t, []Boost{{Name: "freshness", Value: 20}}, candidates[0].Boosts)
+		assert.Equal(t, "video3", candidates[1].VideoID)
+		assert.Equal(t, 100.0, candidates[1].Score)
+		assert.Empty(t, candidates[2].Boosts)
+	})
+
+	t.Run("count params", func(t *testing.T) {
+		strategy := NewPipeline(client, []CandidateGenerator{GlobalTop{Redis: client, TopM: 10}}, nil)
+		for _, topM := range []float64{0, -1, math.NaN()} {
+			candidates, err := strategy.Rank(ctx, Request{UserID: "user1", Params: map[string]float64{"global_top_m": topM}})
+			require.NoError(t, err)
+			assert.Len(t, candidates, 1, topM)
+		}
+		assert.Equal(t, int64(maxCount), Request{Params: map[string]float64{"global_top_m": 1e12}}.Count("global_top_m", 10))
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		_, ok := registry.Get("missing")
+		assert.False(t, ok)
+	})
+}
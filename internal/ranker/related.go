@@ -0,0 +1,151 @@
+package ranker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"realtime_ranking/internal/moderation"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func relatedKey(videoID string) string {
+	return fmt.Sprintf("video:%s:related", videoID)
+}
+
+// relatedAtKey holds when each video of relatedKey was last paired, in microseconds
+func relatedAtKey(videoID string) string {
+	return fmt.Sprintf("video:%s:related:at", videoID)
+}
+
+// pair increments the co-interaction counts of the video ARGV[1] and each of ARGV[4:] in
+// both directions, at time ARGV[2]. KEYS are the counts and pairing times of the video,
+// then of each other video in order. A set over ARGV[3] entries evicts, among its
+// lowest counts, the entry paired the longest ago, never the one just paired, so that
+// new pairs get the chance to grow.
+var pair = redis.NewScript(`
+local video = ARGV[1]
+local now = ARGV[2]
+local size = tonumber(ARGV[3])
+
+local function record(counts, times, member)
+	redis.call('ZINCRBY', counts, 1, member)
+	redis.call('ZADD', times, now, member)
+	while redis.call('ZCARD', counts) > size do
+		local lowest = nil
+		local entries = redis.call('ZRANGE', counts, 0, 1, 'WITHSCORES')
+		for i = 1, #entries, 2 do
+			if lowest == nil and entries[i] ~= member then
+				lowest = entries[i + 1]
+			end
+		end
+		if lowest == nil then
+			return
+		end
+		local victim = nil
+		local victimAt = nil
+		for _, candidate in ipairs(redis.call('ZRANGEBYSCORE', counts, lowest, lowest)) do
+			if candidate ~= member then
+				local at = tonumber(redis.call('ZSCORE', times, candidate) or 0)
+				if victim == nil or at < victimAt then
+					victim = candidate
+					victimAt = at
+				end
+			end
+		end
+		redis.call('ZREM', counts, victim)
+		redis.call('ZREM', times, victim)
+	end
+end
+
+for i = 4, #ARGV do
+	local k = 2 * (i - 3) + 1
+	record(KEYS[1], KEYS[2], ARGV[i])
+	record(KEYS[k], KEYS[k + 1], video)
+end
+return #ARGV - 3
+`)
+
+// CoInteractionIndex counts, for each video, how many users interacted with it and with
+// each other video. Counts are kept in a sorted set per video, bounded to the size most
+// co-interacted videos; ties are broken in favour of the most recently paired ones.
+type CoInteractionIndex struct {
+	redis *redis.Client
+	// window is the number of most recently seen videos a new interaction is paired with
+	window int64
+	size   int64
+}
+
+func NewCoInteractionIndex(redis *redis.Client, window, size int64) *CoInteractionIndex {
+	return &CoInteractionIndex{
+		redis:  redis,
+		window: window,
+		size:   size,
+	}
+}
+
+// Record pairs a video with the videos the user saw recently. It reads the seen history
+// and must run before the interaction is added to it; videos already in the history are
+// not paired again.
+func (i *CoInteractionIndex) Record(ctx context.Context, userID, videoID string) error {
+	key := seenKey(userID)
+	if err := i.redis.ZScore(ctx, key, videoID).Err(); err == nil {
+		return nil
+	} else if !errors.Is(err, redis.Nil) {
+		return err
+	}
+	recent, err := i.redis.ZRevRange(ctx, key, 0, i.window-1).Result()
+	if err != nil || len(recent) == 0 {
+		return err
+	}
+
+	keys := []string{relatedKey(videoID), relatedAtKey(videoID)}
+	args := []any{videoID, time.Now().UnixMicro(), i.size}
+	for _, other := range recent {
+		keys = append(keys, relatedKey(other), relatedAtKey(other))
+		args = append(args, other)
+	}
+	return pair.Run(ctx, i.redis, keys, args...).Err()
+}
+
+// Related returns the videos most co-interacted with a video, with their counts, leaving
+// out the hidden videos
+func (i *CoInteractionIndex) Related(ctx context.Context, videoID string, offset, limit int64) ([]redis.Z, error) {
+	return moderation.Range(ctx, i.redis, relatedKey(videoID), nil, offset, limit)
+}
+
+// CoInteracted generates the videos most co-interacted with the Seeds videos the user saw
+// last, "users who watched this also watched". TopK can be overridden with the
+// co_interaction_top_k param.
+type CoInteracted struct {
+	Redis *redis.Client
+	Seeds int64
+	TopK  int64
+}
+
+func (g CoInteracted) Name() string { return "co_interaction" }
+
+func (g CoInteracted) Params() []string { return []string{"co_interaction_top_k"} }
+
+func (g CoInteracted) Generate(ctx context.Context, req Request, user *UserProfile) ([]string, error) {
+	topK := req.Count("co_interaction_top_k", g.TopK)
+	seeds, err := g.Redis.ZRevRange(ctx, seenKey(req.UserID), 0, g.Seeds-1).Result()
+	if err != nil || len(seeds) == 0 {
+		return nil, err
+	}
+
+	pipe := g.Redis.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(seeds))
+	for i, seed := range seeds {
+		cmds[i] = pipe.ZRevRange(ctx, relatedKey(seed), 0, topK-1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	var videoIDs []string
+	for _, cmd := range cmds {
+		videoIDs = append(videoIDs, cmd.Val()...)
+	}
+	return videoIDs, nil
+}
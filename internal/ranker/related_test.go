@@ -0,0 +1,85 @@
+package ranker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoInteractions(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	history := NewSeenHistory(client, 500, time.Hour)
+	index := NewCoInteractionIndex(client, 2, 10)
+	now := time.Unix(1690000000, 0)
+	interact := func(t *testing.T, userID, videoID string) {
+		require.NoError(t, index.Record(ctx, userID, videoID))
+		require.NoError(t, history.Record(ctx, userID, videoID, now))
+		now = now.Add(time.Minute)
+	}
+
+	interact(t, "user2", "video1")
+	interact(t, "user2", "video2")
+	interact(t, "user2", "video1")
+	interact(t, "user3", "video2")
+	interact(t, "user3", "video1")
+	interact(t, "user3", "video3")
+
+	t.Run("counts", func(t *testing.T) {
+		related, err := index.Related(ctx, "video1", 0, 10)
+		require.NoError(t, err)
+		// repeated interactions are paired once
+		require.Len(t, related, 2)
+		assert.Equal(t, "video2", related[0].Member)
+		assert.Equal(t, 2.0, related[0].Score)
+		assert.Equal(t, "video3", related[1].Member)
+		assert.Equal(t, 1.0, related[1].Score)
+	})
+
+	t.Run("window", func(t *testing.T) {
+		interact(t, "user3", "video4")
+		related, err := index.Related(ctx, "video4", 0, 10)
+		require.NoError(t, err)
+		// video2 is older than the last two seen videos
+		require.Len(t, related, 2)
+		assert.ElementsMatch(t, []interface{}{"video1", "video3"}, []interface{}{related[0].Member, related[1].Member})
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		index := NewCoInteractionIndex(client, 1, 2)
+		pairWith := func(t *testing.T, userID, seen string) {
+			mr.ZAdd("user:"+userID+":seen", 1690000000, seen)
+			require.NoError(t, index.Record(ctx, userID, "hub"))
+		}
+		pairWith(t, "userA", "x")
+		pairWith(t, "userB", "x")
+		pairWith(t, "userC", "y")
+		// the new pair is kept, the older one with the lowest count goes
+		pairWith(t, "userD", "w")
+
+		related, err := index.Related(ctx, "hub", 0, 10)
+		require.NoError(t, err)
+		require.Len(t, related, 2)
+		assert.Equal(t, "x", related[0].Member)
+		assert.Equal(t, "w", related[1].Member)
+		paired, err := mr.ZMembers("video:hub:related:at")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"x", "w"}, paired)
+	})
+
+	t.Run("candidate source", func(t *testing.T) {
+		mr.ZAdd("user:user1:seen", 1690000000, "video4")
+		strategy := NewPipeline(client, []CandidateGenerator{CoInteracted{Redis: client, Seeds: 1, TopK: 2}}, nil)
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user1"})
+		require.NoError(t, err)
+		require.Len(t, candidates, 2)
+		assert.Equal(t, "video3", candidates[0].VideoID)
+		assert.Equal(t, "video1", candidates[1].VideoID)
+		assert.Equal(t, []string{"co_interaction"}, candidates[0].Sources)
+	})
+}
@@ -0,0 +1,156 @@
+package ranker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Seen policies for videos the user already interacted with
+const (
+	SeenExclude = "exclude" // drop seen videos
+	SeenDemote  = "demote"  // subtract the seen weight
+	SeenBoost   = "boost"   // add the seen weight
+)
+
+func seenKey(userID string) string {
+	return fmt.Sprintf("user:%s:seen", userID)
+}
+
+// SeenHistory keeps the last interaction time of the most recent videos of each user
+// in a sorted set, bounded by size and expiring after ttl without interactions.
+type SeenHistory struct {
+	redis *redis.Client
+	size  int64
+	ttl   time.Duration
+}
+
+func NewSeenHistory(redis *redis.Client, size int64, ttl time.Duration) *SeenHistory {
+	return &SeenHistory{
+		redis: redis,
+		size:  size,
+		ttl:   ttl,
+	}
+}
+
+// Record marks a video as seen by a user at the given time
+func (h *SeenHistory) Record(ctx context.Context, userID, videoID string, at time.Time) error {
+	key := seenKey(userID)
+	_, err := h.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.Unix()), Member: videoID})
+		pipe.ZRemRangeByRank(ctx, key, 0, -h.size-1)
+		pipe.Expire(ctx, key, h.ttl)
+		return nil
+	})
+	return err
+}
+
+// legacyInteractionsKey is the unbounded set of interacted videos the seen history replaced
+func legacyInteractionsKey(userID string) string {
+	return fmt.Sprintf("user:%s:interactions", userID)
+}
+
+// MigrateResult counts the legacy interaction sets moved into the seen history
+type MigrateResult struct {
+	Users  int `json:"users"`
+	Videos int `json:"videos"`
+}
+
+// Migrate moves the legacy user:<id>:interactions sets into the seen history and deletes
+// them. The sets hold no interaction times, so their videos are recorded as seen at the
+// given time, without overwriting the videos already in the history. Histories are then
+// trimmed to size, which drops the migrated videos first when at is the oldest time.
+func (h *SeenHistory) Migrate(ctx context.Context, at time.Time) (MigrateResult, error) {
+	var result MigrateResult
+	iter := h.redis.Scan(ctx, 0, legacyInteractionsKey("*"), 1000).Iterator()
+	for iter.Next(ctx) {
+		legacyKey := iter.Val()
+		userID := strings.TrimSuffix(strings.TrimPrefix(legacyKey, "user:"), ":interactions")
+		videoIDs, err := h.redis.SMembers(ctx, legacyKey).Result()
+		if err != nil {
+			return result, fmt.Errorf("read %s: %w", legacyKey, err)
+		}
+		members := make([]redis.Z, len(videoIDs))
+		for i, videoID := range videoIDs {
+			members[i] = redis.Z{Score: float64(at.Unix()), Member: videoID}
+		}
+
+		key := seenKey(userID)
+		_, err = h.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if len(members) > 0 {
+				pipe.ZAddNX(ctx, key, members...)
+				pipe.ZRemRangeByRank(ctx, key, 0, -h.size-1)
+				pipe.Expire(ctx, key, h.ttl)
+			}
+			pipe.Del(ctx, legacyKey)
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("migrate %s: %w", legacyKey, err)
+		}
+		result.Users++
+		result.Videos += len(videoIDs)
+	}
+	return result, iter.Err()
+}
+
+// SeenPolicy applies the configured policy to candidates the user saw within Window
+type SeenPolicy struct {
+	Policy string
+	// Weight is subtracted (demote) or added (boost) to the score of seen videos.
+	// It can be overridden with the seen_weight param.
+	Weight float64
+	Window time.Duration
+	Now    func() time.Time
+}
+
+func (s SeenPolicy) Name() string { return "seen" }
+
+func (s SeenPolicy) Params() []string { return []string{"seen_weight"} }
+
+func (s SeenPolicy) seen(user *UserProfile, videoID string) bool {
+	at, ok := user.Seen[videoID]
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	return s.Window <= 0 || now.Sub(at) <= s.Window
+}
+
+// Filter drops seen candidates under the exclude policy
+func (s SeenPolicy) Filter(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error) {
+	if s.Policy != SeenExclude {
+		return candidates, nil
+	}
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if !s.seen(user, candidate.VideoID) {
+			kept = append(kept, candidate)
+		}
+	}
+	return kept, nil
+}
+
+// Score demotes or boosts seen candidates
+func (s SeenPolicy) Score(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) error {
+	weight := req.Param("seen_weight", s.Weight)
+	switch s.Policy {
+	case SeenDemote:
+		weight = -weight
+	case SeenBoost:
+	default:
+		return nil
+	}
+	for _, candidate := range candidates {
+		if s.seen(user, candidate.VideoID) {
+			candidate.Boost(s.Name(), weight)
+		}
+	}
+	return nil
+}
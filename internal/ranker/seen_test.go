@@ -0,0 +1,93 @@
+package ranker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeenPolicy(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	now := time.Unix(1690000000, 0)
+	history := NewSeenHistory(client, 2, time.Hour)
+	require.NoError(t, history.Record(ctx, "user2", "video1", now.Add(-48*time.Hour)))
+	require.NoError(t, history.Record(ctx, "user2", "video2", now.Add(-time.Hour)))
+
+	rank := func(t *testing.T, policy string) []*Candidate {
+		seen := SeenPolicy{Policy: policy, Weight: 100, Window: 24 * time.Hour, Now: func() time.Time { return now }}
+		strategy := NewPipeline(client,
+			[]CandidateGenerator{GlobalTop{Redis: client, TopM: 10}},
+			[]Scorer{seen},
+		).WithFilters(seen)
+		candidates, err := strategy.Rank(ctx, Request{UserID: "user2"})
+		require.NoError(t, err)
+		return candidates
+	}
+	ids := func(candidates []*Candidate) []string {
+		var videoIDs []string
+		for _, candidate := range candidates {
+			videoIDs = append(videoIDs, candidate.VideoID)
+		}
+		return videoIDs
+	}
+
+	// video1 was seen outside the window and is treated as unseen
+	t.Run("exclude", func(t *testing.T) {
+		assert.Equal(t, []string{"video3", "video1"}, ids(rank(t, SeenExclude)))
+	})
+
+	t.Run("demote", func(t *testing.T) {
+		candidates := rank(t, SeenDemote)
+		assert.Equal(t, []string{"video3", "video1", "video2"}, ids(candidates))
+		assert.Equal(t, []Boost{{Name: "seen", Value: -100}}, candidates[2].Boosts)
+	})
+
+	t.Run("boost", func(t *testing.T) {
+		assert.Equal(t, []string{"video2", "video3", "video1"}, ids(rank(t, SeenBoost)))
+	})
+
+	t.Run("bounded history", func(t *testing.T) {
+		require.NoError(t, history.Record(ctx, "user2", "video3", now))
+		members, err := mr.ZMembers("user:user2:seen")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"video2", "video3"}, members)
+		assert.Equal(t, time.Hour, mr.TTL("user:user2:seen"))
+	})
+}
+
+func TestSeenHistoryMigrate(t *testing.T) {
+	client, mr := setupTest(t)
+	defer mr.Close()
+
+	ctx := context.Background()
+	at := time.Unix(1680000000, 0)
+	mr.SAdd("user:user1:interactions", "video1", "video3")
+	mr.SAdd("user:user2:interactions", "video1", "video2", "video4")
+
+	history := NewSeenHistory(client, 2, time.Hour)
+	result, err := history.Migrate(ctx, at)
+	require.NoError(t, err)
+	assert.Equal(t, MigrateResult{Users: 2, Videos: 5}, result)
+	assert.False(t, mr.Exists("user:user1:interactions"))
+	assert.False(t, mr.Exists("user:user2:interactions"))
+
+	// the recorded time of video3 is kept and the migrated video1 is older
+	members, err := mr.ZMembers("user:user1:seen")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"video1", "video3"}, members)
+	score, err := mr.ZScore("user:user1:seen", "video3")
+	require.NoError(t, err)
+	assert.Equal(t, 1690000000.0, score)
+
+	// histories are trimmed to size
+	members, err = mr.ZMembers("user:user2:seen")
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Greater(t, mr.TTL("user:user2:seen"), time.Duration(0))
+}
@@ -0,0 +1,225 @@
+package ranker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"realtime_ranking/internal/moderation"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Names of the built-in strategies
+const (
+	StrategyDefault = "default"
+	StrategyFresh   = "fresh"
+	StrategyGlobal  = "global"
+)
+
+// FollowedCreators generates the top videos of each creator the user follows.
+// TopK can be overridden with the followed_top_k param.
+type FollowedCreators struct {
+	Redis *redis.Client
+	TopK  int64
+}
+
+func (g FollowedCreators) Name() string { return "followed_creators" }
+
+func (g FollowedCreators) Params() []string { return []string{"followed_top_k"} }
+
+func (g FollowedCreators) Generate(ctx context.Context, req Request, user *UserProfile) ([]string, error) {
+	topK := req.Count("followed_top_k", g.TopK)
+	pipe := g.Redis.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, 0, len(user.Follows))
+	for creatorID := range user.Follows {
+		cmds = append(cmds, pipe.ZRevRange(ctx, fmt.Sprintf("creator:%s:videos", creatorID), 0, topK-1))
+	}
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	var videoIDs []string
+	for _, cmd := range cmds {
+		videoIDs = append(videoIDs, cmd.Val()...)
+	}
+	return videoIDs, nil
+}
+
+// GlobalTop generates the top videos of the global ranking.
+// TopM can be overridden with the global_top_m param.
+type GlobalTop struct {
+	Redis *redis.Client
+	TopM  int64
+}
+
+func (g GlobalTop) Name() string { return "global_top" }
+
+func (g GlobalTop) Params() []string { return []string{"global_top_m"} }
+
+func (g GlobalTop) Generate(ctx context.Context, req Request, user *UserProfile) ([]string, error) {
+	topM := req.Count("global_top_m", g.TopM)
+	return g.Redis.ZRevRange(ctx, "rankings:global", 0, topM-1).Result()
+}
+
+// Exclusions drops the videos hidden from public boards by moderation, and the videos
+// and creators the user hid or is not interested in
+type Exclusions struct {
+	Redis *redis.Client
+}
+
+func (f Exclusions) Name() string { return "exclusions" }
+
+func (f Exclusions) Filter(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) ([]*Candidate, error) {
+	videoIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		videoIDs[i] = candidate.VideoID
+	}
+	moderated, err := moderation.HiddenAmong(ctx, f.Redis, videoIDs)
+	if err != nil {
+		return nil, err
+	}
+	kept := candidates[:0]
+	for _, candidate := range candidates {
+		if _, ok := moderated[candidate.VideoID]; ok {
+			continue
+		}
+		if _, ok := user.Hidden[candidate.VideoID]; ok {
+			continue
+		}
+		if _, ok := user.NotInterested[candidate.CreatorID]; ok && candidate.CreatorID != "" {
+			continue
+		}
+		kept = append(kept, candidate)
+	}
+	return kept, nil
+}
+
+// FollowBoost adds a fixed boost to videos of followed creators.
+// Boost can be overridden with the follow_boost param.
+type FollowBoost struct {
+	Boost float64
+}
+
+func (s FollowBoost) Name() string { return "follow" }
+
+func (s FollowBoost) Params() []string { return []string{"follow_boost"} }
+
+func (s FollowBoost) Score(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) error {
+	boost := req.Param("follow_boost", s.Boost)
+	for _, candidate := range candidates {
+		if _, ok := user.Follows[candidate.CreatorID]; ok && candidate.CreatorID != "" {
+			candidate.Boost(s.Name(), boost)
+		}
+	}
+	return nil
+}
+
+// FreshnessBoost favors recently published videos with a boost that halves every
+// HalfLife since the created_at unix timestamp of the video hash.
+// Boost can be overridden with the freshness_boost param.
+type FreshnessBoost struct {
+	Redis    *redis.Client
+	Boost    float64
+	HalfLife time.Duration
+	Now      func() time.Time
+}
+
+func (s FreshnessBoost) Name() string { return "freshness" }
+
+func (s FreshnessBoost) Params() []string { return []string{"freshness_boost"} }
+
+func (s FreshnessBoost) Score(ctx context.Context, req Request, user *UserProfile, candidates []*Candidate) error {
+	boost := req.Param("freshness_boost", s.Boost)
+	pipe := s.Redis.Pipeline()
+	cmds := make([]*redis.StringCmd, len(candidates))
+	for i, candidate := range candidates {
+		cmds[i] = pipe.HGet(ctx, fmt.Sprintf("video:%s", candidate.VideoID), "created_at")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+	for i, candidate := range candidates {
+		createdAt, err := strconv.ParseInt(cmds[i].Val(), 10, 64)
+		if err != nil {
+			continue
+		}
+		age := max(now.Sub(time.Unix(createdAt, 0)), 0)
+		candidate.Boost(s.Name(), boost*math.Pow(0.5, age.Hours()/s.HalfLife.Hours()))
+	}
+	return nil
+}
+
+// Config configures the built-in strategies
+type Config struct {
+	// Strategy is the strategy used when none is requested
+	Strategy string
+	// SeenPolicy is exclude, demote or boost, applied to videos seen within SeenWindow
+	SeenPolicy string
+	SeenWeight float64
+	SeenWindow time.Duration
+	// CreatorMax and TopicMax cap the videos of a creator and of a category or tag in
+	// any DiversityWindow consecutive results, 0 disables the cap
+	CreatorMax      int
+	TopicMax        int
+	DiversityWindow int
+	// InterleaveRatio is the share of followed-creator videos in every prefix of the
+	// results, 0 disables interleaving
+	InterleaveRatio float64
+}
+
+// DefaultRegistry registers the built-in strategies:
+//   - default: followed creators, the global top and videos co-interacted with the last seen
+//     ones, boosted by follows, with the seen policy and the diversity constraints
+//   - fresh: default with an additional boost for recently published videos
+//   - global: the global top by score only
+//
+// Every strategy leaves out moderated videos and the videos the user hid or is not interested in.
+func DefaultRegistry(redis *redis.Client, config Config) *Registry {
+	exclusions := Exclusions{Redis: redis}
+	seen := SeenPolicy{Policy: config.SeenPolicy, Weight: config.SeenWeight, Window: config.SeenWindow}
+	diversity := []Reranker{
+		Interleave{Ratio: config.InterleaveRatio},
+		TopicSpread(config.TopicMax, config.DiversityWindow),
+		CreatorSpread(config.CreatorMax, config.DiversityWindow),
+	}
+
+	registry := NewRegistry(config.Strategy)
+	registry.Register(StrategyDefault, NewPipeline(redis,
+		[]CandidateGenerator{
+			FollowedCreators{Redis: redis, TopK: 10},
+			GlobalTop{Redis: redis, TopM: 50},
+			CoInteracted{Redis: redis, Seeds: 10, TopK: 10},
+		},
+		[]Scorer{
+			FollowBoost{Boost: 100},
+			seen,
+		},
+	).WithFilters(exclusions, seen).WithRerankers(diversity...))
+	registry.Register(StrategyFresh, NewPipeline(redis,
+		[]CandidateGenerator{
+			FollowedCreators{Redis: redis, TopK: 10},
+			GlobalTop{Redis: redis, TopM: 50},
+			CoInteracted{Redis: redis, Seeds: 10, TopK: 10},
+		},
+		[]Scorer{
+			FollowBoost{Boost: 100},
+			seen,
+			FreshnessBoost{Redis: redis, Boost: 100, HalfLife: 24 * time.Hour},
+		},
+	).WithFilters(exclusions, seen).WithRerankers(diversity...))
+	registry.Register(StrategyGlobal, NewPipeline(redis,
+		[]CandidateGenerator{GlobalTop{Redis: redis, TopM: 100}},
+		nil,
+	).WithFilters(exclusions))
+	return registry
+}
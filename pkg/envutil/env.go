@@ -0,0 +1,48 @@
+package envutil
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+func GetString(key string, defaultV string) string {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultV
+	}
+	return v
+}
+
+func GetInt(key string, defaultV int) int {
+	num, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultV
+	}
+	return num
+}
+
+func GetFloat(key string, defaultV float64) float64 {
+	num, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultV
+	}
+	return num
+}
+
+func GetBool(key string, defaultV bool) bool {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultV
+	}
+	return b
+}
+
+// GetDuration parses values such as "5m" or "1h30m"
+func GetDuration(key string, defaultV time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultV
+	}
+	return d
+}
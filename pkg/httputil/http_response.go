@@ -4,4 +4,13 @@ type HttpResponse struct {
 	Code int `json:"code"`
 	//Total int64 `json:"total,omitempty"`
 	Data any `json:"data"`
+	// Experiment identifies the experiment variant that served the response
+	Experiment *ExperimentInfo `json:"experiment,omitempty"`
+	// NextCursor is passed back to get the next page of cursor paginated responses
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ExperimentInfo struct {
+	ID      string `json:"id"`
+	Variant string `json:"variant"`
 }
@@ -0,0 +1,335 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"realtime_ranking/pkg/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Role grants access to a set of endpoints. Each role includes the roles below it.
+type Role string
+
+const (
+	// RolePublic reads the public rankings, it is granted to anonymous requests
+	RolePublic Role = "public"
+	// RoleIngest additionally sends interactions and follows
+	RoleIngest Role = "ingest"
+	// RoleAdmin additionally uses the admin endpoints
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RolePublic: 0,
+	RoleIngest: 1,
+	RoleAdmin:  2,
+}
+
+// Includes reports whether the role grants at least the access of other
+func (r Role) Includes(other Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[other]
+}
+
+// Authentication methods
+const (
+	AuthAnonymous = "anonymous"
+	AuthAPIKey    = "api_key"
+	AuthJWT       = "jwt"
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID     string `json:"id"`
+	Role   Role   `json:"role"`
+	Method string `json:"method"`
+	// Service principals are trusted backends that act on behalf of any user,
+	// other principals only act as the user of their ID
+	Service bool `json:"service,omitempty"`
+}
+
+// ActsFor reports whether the principal may act on behalf of userID
+func (p Principal) ActsFor(userID string) bool {
+	return p.Service || p.ID == userID
+}
+
+// Anonymous is the principal of requests without credentials
+var Anonymous = Principal{ID: "anonymous", Role: RolePublic, Method: AuthAnonymous}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the principal set by Authenticate, or Anonymous
+func PrincipalFrom(ctx context.Context) Principal {
+	if principal, ok := ctx.Value(principalKey{}).(Principal); ok {
+		return principal
+	}
+	return Anonymous
+}
+
+var (
+	ErrorUnauthorized = HttpError{
+		Code: http.StatusUnauthorized,
+		Err:  errors.New("invalid credentials"),
+	}
+	ErrorAuthenticationRequired = HttpError{
+		Code: http.StatusUnauthorized,
+		Err:  errors.New("authentication required"),
+	}
+	ErrorForbidden = HttpError{
+		Code: http.StatusForbidden,
+		Err:  errors.New("insufficient role"),
+	}
+)
+
+// APIKeyHeader carries API keys, JWTs are passed as Authorization: Bearer tokens
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyKey is the Redis hash holding the id, role and service flag of an API key, by the
+// SHA-256 of the key
+func apiKeyKey(hash string) string {
+	return fmt.Sprintf("apikey:%s", hash)
+}
+
+// HashAPIKey returns the hex SHA-256 under which an API key is stored
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// StoreAPIKey registers an API key for a principal. Only the hash of the key is stored.
+func StoreAPIKey(ctx context.Context, rdb *redis.Client, key string, principal Principal) error {
+	return rdb.HSet(ctx, apiKeyKey(HashAPIKey(key)),
+		"id", principal.ID,
+		"role", string(principal.Role),
+		"service", strconv.FormatBool(principal.Service),
+	).Err()
+}
+
+// RevokeAPIKey removes an API key
+func RevokeAPIKey(ctx context.Context, rdb *redis.Client, key string) error {
+	return rdb.Del(ctx, apiKeyKey(HashAPIKey(key))).Err()
+}
+
+// Authenticator resolves the principal of requests from API keys and HS256 or RS256 JWTs
+type Authenticator struct {
+	redis *redis.Client
+	// hmacSecret verifies HS256 tokens and rsaKey RS256 tokens, a nil key rejects the algorithm
+	hmacSecret []byte
+	rsaKey     *rsa.PublicKey
+	issuer     string
+	now        func() time.Time
+}
+
+// NewAuthenticator verifies JWTs with hmacSecret and rsaKey, either may be empty.
+// When issuer is set, tokens must carry it in their iss claim.
+func NewAuthenticator(redis *redis.Client, hmacSecret []byte, rsaKey *rsa.PublicKey, issuer string) *Authenticator {
+	return &Authenticator{
+		redis:      redis,
+		hmacSecret: hmacSecret,
+		rsaKey:     rsaKey,
+		issuer:     issuer,
+		now:        time.Now,
+	}
+}
+
+// ParseRSAPublicKey parses a PEM encoded PKIX or PKCS #1 RSA public key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
+
+// Authenticate returns the principal of a request: Anonymous without credentials,
+// ErrorUnauthorized when the credentials are invalid
+func (a *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(r.Context(), key)
+	}
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return Principal{}, ErrorUnauthorized
+		}
+		return a.authenticateJWT(strings.TrimSpace(token))
+	}
+	return Anonymous, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	values, err := a.redis.HGetAll(ctx, apiKeyKey(HashAPIKey(key))).Result()
+	if err != nil {
+		return Principal{}, err
+	}
+	service, _ := strconv.ParseBool(values["service"])
+	principal := Principal{ID: values["id"], Role: Role(values["role"]), Method: AuthAPIKey, Service: service}
+	if _, ok := roleLevels[principal.Role]; !ok || principal.ID == "" {
+		return Principal{}, ErrorUnauthorized
+	}
+	return principal, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Role      Role   `json:"role"`
+	Service   bool   `json:"service"`
+	Issuer    string `json:"iss"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// authenticateJWT verifies a compact JWT. The algorithm must match a configured key
+// and the token must carry sub, role and exp claims. A true service claim makes a service principal.
+func (a *Authenticator) authenticateJWT(token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, ErrorUnauthorized
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, ErrorUnauthorized
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, ErrorUnauthorized
+	}
+	signed := parts[0] + "." + parts[1]
+	switch header.Alg {
+	case "HS256":
+		if len(a.hmacSecret) == 0 {
+			return Principal{}, ErrorUnauthorized
+		}
+		mac := hmac.New(sha256.New, a.hmacSecret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return Principal{}, ErrorUnauthorized
+		}
+	case "RS256":
+		if a.rsaKey == nil {
+			return Principal{}, ErrorUnauthorized
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(a.rsaKey, crypto.SHA256, digest[:], signature); err != nil {
+			return Principal{}, ErrorUnauthorized
+		}
+	default:
+		return Principal{}, ErrorUnauthorized
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, ErrorUnauthorized
+	}
+	now := a.now().Unix()
+	if claims.ExpiresAt == nil || now >= *claims.ExpiresAt {
+		return Principal{}, ErrorUnauthorized
+	}
+	if claims.NotBefore != nil && now < *claims.NotBefore {
+		return Principal{}, ErrorUnauthorized
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return Principal{}, ErrorUnauthorized
+	}
+	if _, ok := roleLevels[claims.Role]; !ok || claims.Subject == "" {
+		return Principal{}, ErrorUnauthorized
+	}
+	return Principal{ID: claims.Subject, Role: claims.Role, Method: AuthJWT, Service: claims.Service}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// AuthWrap authenticates every request and stores its principal in the request context.
+// Requests with invalid credentials are rejected with 401, requests without credentials
+// go through as Anonymous and are authorized per endpoint by RequireRole.
+func AuthWrap(h http.Handler, auth *Authenticator, logger *zap.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := auth.Authenticate(r)
+		if err != nil {
+			var httpErr HttpError
+			if !errors.As(err, &httpErr) {
+				logger.Error("failed to authenticate request", zap.Error(err))
+				httputil.RenderJSON(http.StatusInternalServerError, w, httputil.ErrorResponse{
+					Message: "internal server",
+					Code:    http.StatusInternalServerError,
+				})
+				return
+			}
+			httputil.RenderJSON(httpErr.ToHttpCode(), w, httpErr.ToHttpResponse())
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireRole rejects requests whose principal does not include role with 401 when
+// anonymous and 403 otherwise
+func RequireRole(role Role, runner Endpoint) Endpoint {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		principal := PrincipalFrom(r.Context())
+		if !principal.Role.Includes(role) {
+			if principal.Method == AuthAnonymous {
+				return ErrorAuthenticationRequired
+			}
+			return ErrorForbidden
+		}
+		return runner(w, r)
+	}
+}
+
+// RequireService only lets service principals granted role, and admins, run the endpoint.
+// It guards the writes that end-user credentials must not reach even with the role.
+func RequireService(role Role, runner Endpoint) Endpoint {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		principal := PrincipalFrom(r.Context())
+		if principal.Role.Includes(RoleAdmin) || (principal.Service && principal.Role.Includes(role)) {
+			return runner(w, r)
+		}
+		if principal.Method == AuthAnonymous {
+			return ErrorAuthenticationRequired
+		}
+		return ErrorForbidden
+	}
+}
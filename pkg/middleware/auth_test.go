@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// signJWT builds a compact JWT signed with an HMAC secret or an RSA private key
+func signJWT(t *testing.T, alg string, key any, claims map[string]any) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestAuthenticate(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ctx := context.Background()
+	secret := []byte("secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	now := time.Unix(1690000000, 0)
+	auth := NewAuthenticator(client, secret, &rsaKey.PublicKey, "ranking")
+	auth.now = func() time.Time { return now }
+
+	require.NoError(t, StoreAPIKey(ctx, client, "ingest-key", Principal{ID: "app1", Role: RoleIngest}))
+	assert.False(t, mr.Exists("apikey:ingest-key"), "keys are stored hashed")
+
+	claims := func(role Role, exp time.Time) map[string]any {
+		return map[string]any{"sub": "alice", "role": role, "iss": "ranking", "exp": exp.Unix()}
+	}
+	authenticate := func(header, value string) (Principal, error) {
+		req := httptest.NewRequest("GET", "/api/v1/ranking", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		return auth.Authenticate(req)
+	}
+
+	t.Run("anonymous", func(t *testing.T) {
+		principal, err := authenticate("", "")
+		require.NoError(t, err)
+		assert.Equal(t, Anonymous, principal)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		principal, err := authenticate(APIKeyHeader, "ingest-key")
+		require.NoError(t, err)
+		assert.Equal(t, Principal{ID: "app1", Role: RoleIngest, Method: AuthAPIKey}, principal)
+
+		require.NoError(t, StoreAPIKey(ctx, client, "service-key", Principal{ID: "backend", Role: RoleIngest, Service: true}))
+		principal, err = authenticate(APIKeyHeader, "service-key")
+		require.NoError(t, err)
+		assert.True(t, principal.Service)
+		assert.True(t, principal.ActsFor("alice"))
+
+		_, err = authenticate(APIKeyHeader, "unknown-key")
+		assert.Equal(t, ErrorUnauthorized, err)
+
+		require.NoError(t, RevokeAPIKey(ctx, client, "ingest-key"))
+		_, err = authenticate(APIKeyHeader, "ingest-key")
+		assert.Equal(t, ErrorUnauthorized, err)
+	})
+
+	t.Run("jwt", func(t *testing.T) {
+		token := signJWT(t, "HS256", secret, claims(RoleAdmin, now.Add(time.Hour)))
+		principal, err := authenticate("Authorization", "Bearer "+token)
+		require.NoError(t, err)
+		assert.Equal(t, Principal{ID: "alice", Role: RoleAdmin, Method: AuthJWT}, principal)
+		assert.True(t, principal.ActsFor("alice"))
+		assert.False(t, principal.ActsFor("bob"))
+
+		token = signJWT(t, "RS256", rsaKey, claims(RoleIngest, now.Add(time.Hour)))
+		principal, err = authenticate("Authorization", "Bearer "+token)
+		require.NoError(t, err)
+		assert.Equal(t, RoleIngest, principal.Role)
+	})
+
+	t.Run("invalid jwt", func(t *testing.T) {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		noneToken := signJWT(t, "none", nil, claims(RoleAdmin, now.Add(time.Hour)))
+
+		for name, token := range map[string]string{
+			"wrong secret":   signJWT(t, "HS256", []byte("other"), claims(RoleAdmin, now.Add(time.Hour))),
+			"wrong key":      signJWT(t, "RS256", otherKey, claims(RoleAdmin, now.Add(time.Hour))),
+			"none algorithm": noneToken,
+			"expired":        signJWT(t, "HS256", secret, claims(RoleAdmin, now.Add(-time.Second))),
+			"unknown role":   signJWT(t, "HS256", secret, claims("root", now.Add(time.Hour))),
+			"wrong issuer":   signJWT(t, "HS256", secret, map[string]any{"sub": "alice", "role": RoleAdmin, "iss": "other", "exp": now.Add(time.Hour).Unix()}),
+			"no expiry":      signJWT(t, "HS256", secret, map[string]any{"sub": "alice", "role": RoleAdmin, "iss": "ranking"}),
+			"malformed":      "not-a-token",
+		} {
+			_, err := authenticate("Authorization", "Bearer "+token)
+			assert.Equal(t, ErrorUnauthorized, err, name)
+		}
+
+		_, err = authenticate("Authorization", "Basic YWxpY2U6c2VjcmV0")
+		assert.Equal(t, ErrorUnauthorized, err)
+	})
+
+	t.Run("hs256 disabled", func(t *testing.T) {
+		rsaOnly := NewAuthenticator(client, nil, &rsaKey.PublicKey, "")
+		rsaOnly.now = auth.now
+		req := httptest.NewRequest("GET", "/api/v1/ranking", nil)
+		req.Header.Set("Authorization", "Bearer "+signJWT(t, "HS256", []byte{}, claims(RoleAdmin, now.Add(time.Hour))))
+		_, err := rsaOnly.Authenticate(req)
+		assert.Equal(t, ErrorUnauthorized, err)
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ctx := context.Background()
+	require.NoError(t, StoreAPIKey(ctx, client, "ingest-key", Principal{ID: "app1", Role: RoleIngest}))
+	require.NoError(t, StoreAPIKey(ctx, client, "admin-key", Principal{ID: "ops", Role: RoleAdmin}))
+	require.NoError(t, StoreAPIKey(ctx, client, "service-key", Principal{ID: "backend", Role: RoleIngest, Service: true}))
+
+	logger := zap.NewNop()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /read", WithErrorHandler(func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte(PrincipalFrom(r.Context()).ID))
+		return nil
+	}, logger))
+	mux.HandleFunc("POST /admin", WithErrorHandler(RequireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}), logger))
+	mux.HandleFunc("POST /service", WithErrorHandler(RequireService(RoleIngest, func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}), logger))
+	server := AuthWrap(mux, NewAuthenticator(client, nil, nil, ""), logger)
+
+	serve := func(method, path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve("GET", "/read", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "anonymous", rr.Body.String())
+	rr = serve("GET", "/read", "ingest-key")
+	assert.Equal(t, "app1", rr.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, serve("GET", "/read", "unknown-key").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/admin", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/admin", "ingest-key").Code)
+	assert.Equal(t, http.StatusOK, serve("POST", "/admin", "admin-key").Code)
+
+	assert.Equal(t, http.StatusUnauthorized, serve("POST", "/service", "").Code)
+	assert.Equal(t, http.StatusForbidden, serve("POST", "/service", "ingest-key").Code)
+	assert.Equal(t, http.StatusOK, serve("POST", "/service", "service-key").Code)
+	assert.Equal(t, http.StatusOK, serve("POST", "/service", "admin-key").Code)
+}
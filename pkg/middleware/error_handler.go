@@ -13,6 +13,27 @@ type HttpResponseSerializable interface {
 	ToHttpCode() int
 }
 
+// HttpError is an error rendered with its status code by WithErrorHandler
+type HttpError struct {
+	Code int
+	Err  error
+}
+
+func (e HttpError) Error() string {
+	return e.Err.Error()
+}
+
+func (e HttpError) ToHttpCode() int {
+	return e.Code
+}
+
+func (e HttpError) ToHttpResponse() httputil.ErrorResponse {
+	return httputil.ErrorResponse{
+		Message: e.Err.Error(),
+		Code:    e.Code,
+	}
+}
+
 func WithErrorHandler(runner Endpoint, logger *zap.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := runner(w, r)
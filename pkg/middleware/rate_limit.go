@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Limit allows Rate requests per Period, with bursts of up to Rate requests
+type Limit struct {
+	Rate   int64
+	Period time.Duration
+}
+
+// Enabled reports whether the limit applies
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Period > 0
+}
+
+// ParseLimit parses a "<rate>/<period>" limit such as 100/1m, "" and "0" disable the limit
+func ParseLimit(value string) (Limit, error) {
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+	rate, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid limit %q, expected <rate>/<period>", value)
+	}
+	var limit Limit
+	var err error
+	if limit.Rate, err = strconv.ParseInt(rate, 10, 64); err != nil || limit.Rate < 0 {
+		return Limit{}, fmt.Errorf("invalid rate in limit %q", value)
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return Limit{}, fmt.Errorf("invalid period in limit %q", value)
+	}
+	return limit, nil
+}
+
+// ParseLimits parses comma separated "<name>=<rate>/<period>" limits such as like=30/1m,report=5/1h
+func ParseLimits(value string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid limit %q, expected <name>=<rate>/<period>", entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// RateLimitResult is the state of a limit after a request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int64
+	Remaining int64
+	// RetryAfter is the wait before the next request is allowed when it was denied
+	RetryAfter time.Duration
+	// ResetAfter is the wait before the whole limit is available again
+	ResetAfter time.Duration
+}
+
+// gcra implements the generic cell rate algorithm on the theoretical arrival times stored in
+// KEYS. ARGV holds the current time, then the emission interval and the burst tolerance of
+// each key, in microseconds. The request is only counted when every key allows it. Returns
+// allowed, remaining, retry after and reset after of each key.
+var gcra = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local allowed = true
+local tats = {}
+local results = {}
+for i, key in ipairs(KEYS) do
+	local interval = tonumber(ARGV[2 * i])
+	local tolerance = tonumber(ARGV[2 * i + 1])
+	local tat = tonumber(redis.call('GET', key) or now)
+	if tat < now then
+		tat = now
+	end
+	local new_tat = tat + interval
+	local allow_at = new_tat - tolerance
+	tats[i] = new_tat
+	if now < allow_at then
+		allowed = false
+		results[i] = {0, 0, allow_at - now, tat - now}
+	else
+		results[i] = {1, math.floor((now - allow_at) / interval), 0, new_tat - now, tat - now}
+	end
+end
+local out = {}
+for i, key in ipairs(KEYS) do
+	local result = results[i]
+	if result[1] == 1 then
+		if allowed then
+			redis.call('SET', key, tats[i], 'PX', math.ceil((tats[i] - now) / 1000))
+		else
+			-- the request is not counted, nothing was taken from this limit
+			result = {1, result[2] + 1, 0, result[5]}
+		end
+	end
+	for j = 1, 4 do
+		table.insert(out, result[j])
+	end
+end
+return out
+`)
+
+// RateLimiter is a distributed rate limiter keeping GCRA state in Redis
+type RateLimiter struct {
+	redis  *redis.Client
+	logger *zap.Logger
+	// trustedProxies is the number of proxies in front of the API that append to X-Forwarded-For
+	trustedProxies int
+	now            func() time.Time
+}
+
+func NewRateLimiter(redis *redis.Client, logger *zap.Logger, trustedProxies int) *RateLimiter {
+	return &RateLimiter{
+		redis:          redis,
+		logger:         logger,
+		trustedProxies: trustedProxies,
+		now:            time.Now,
+	}
+}
+
+// Allow counts a request against the limit of key
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	results, err := l.AllowAll(ctx, []string{key}, []Limit{limit})
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return results[0], nil
+}
+
+// AllowAll checks a request against the limit of each key and counts it against all of
+// them only when every limit allows it
+func (l *RateLimiter) AllowAll(ctx context.Context, keys []string, limits []Limit) ([]RateLimitResult, error) {
+	args := []any{l.now().UnixMicro()}
+	for _, limit := range limits {
+		interval := max(limit.Period.Microseconds()/limit.Rate, 1)
+		args = append(args, interval, interval*limit.Rate)
+	}
+	values, err := gcra.Run(ctx, l.redis, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RateLimitResult, len(keys))
+	for i, limit := range limits {
+		value := values[4*i:]
+		results[i] = RateLimitResult{
+			Allowed:    value[0] == 1,
+			Limit:      limit.Rate,
+			Remaining:  value[1],
+			RetryAfter: time.Duration(value[2]) * time.Microsecond,
+			ResetAfter: time.Duration(value[3]) * time.Microsecond,
+		}
+	}
+	return results, nil
+}
+
+// ClientIP returns the IP of the client. Behind trusted proxies it is the X-Forwarded-For entry
+// appended by the outermost one, counting from the right, since the entries left of it are
+// sent by the client and can be spoofed.
+func (l *RateLimiter) ClientIP(r *http.Request) string {
+	if l.trustedProxies > 0 {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			return strings.TrimSpace(entries[max(len(entries)-l.trustedProxies, 0)])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// APIKeyID returns the principal of requests authenticated with an API key
+func APIKeyID(r *http.Request) string {
+	if principal := PrincipalFrom(r.Context()); principal.Method == AuthAPIKey {
+		return principal.ID
+	}
+	return ""
+}
+
+// RateRule limits the requests sharing the identity returned by Key, requests with an
+// empty identity are not limited by the rule
+type RateRule struct {
+	Name  string
+	Key   func(r *http.Request) string
+	Limit Limit
+}
+
+var ErrorRateLimited = HttpError{
+	Code: http.StatusTooManyRequests,
+	Err:  errors.New("rate limit exceeded"),
+}
+
+// Wrap checks each request against every enabled rule of the route and rejects it with 429
+// and Retry-After when any limit is exceeded. A rejected request is not counted against any
+// limit. X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset describe the most
+// restrictive limit. Requests are let through when Redis is unavailable.
+func (l *RateLimiter) Wrap(route string, rules []RateRule, runner Endpoint) Endpoint {
+	if l == nil {
+		return runner
+	}
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var keys []string
+		var limits []Limit
+		for _, rule := range rules {
+			if !rule.Limit.Enabled() {
+				continue
+			}
+			identity := rule.Key(r)
+			if identity == "" {
+				continue
+			}
+			keys = append(keys, fmt.Sprintf("ratelimit:%s:%s:%s", route, rule.Name, identity))
+			limits = append(limits, rule.Limit)
+		}
+		if len(keys) == 0 {
+			return runner(w, r)
+		}
+		results, err := l.AllowAll(r.Context(), keys, limits)
+		if err != nil {
+			l.logger.Error("failed to apply rate limit", zap.Strings("keys", keys), zap.Error(err))
+			return runner(w, r)
+		}
+
+		var tightest *RateLimitResult
+		var retryAfter time.Duration
+		for i, result := range results {
+			if !result.Allowed {
+				retryAfter = max(retryAfter, result.RetryAfter)
+			}
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &results[i]
+			}
+		}
+
+		if tightest != nil {
+			w.Header().Set("X-RateLimit-Limit", strconv.FormatInt(tightest.Limit, 10))
+			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(tightest.Remaining, 10))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(tightest.ResetAfter), 10))
+		}
+		if retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(retryAfter), 10))
+			return ErrorRateLimited
+		}
+		return runner(w, r)
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
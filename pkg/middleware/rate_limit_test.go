@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 100, Period: time.Minute}, limit)
+
+	limit, err = ParseLimit("0")
+	require.NoError(t, err)
+	assert.False(t, limit.Enabled())
+
+	for _, value := range []string{"100", "x/1m", "100/x", "100/0s"} {
+		_, err := ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+
+	limits, err := ParseLimits("like=30/1m, report=5/1h")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Limit{"like": {30, time.Minute}, "report": {5, time.Hour}}, limits)
+	_, err = ParseLimits("like")
+	assert.Error(t, err)
+}
+
+func TestRateLimiter(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ctx := context.Background()
+	now := time.Unix(1690000000, 0)
+	limiter := NewRateLimiter(client, zap.NewNop(), 0)
+	limiter.now = func() time.Time { return now }
+	limit := Limit{Rate: 3, Period: time.Minute}
+
+	t.Run("allow", func(t *testing.T) {
+		for remaining := int64(2); remaining >= 0; remaining-- {
+			result, err := limiter.Allow(ctx, "key", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, remaining, result.Remaining)
+		}
+
+		result, err := limiter.Allow(ctx, "key", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Equal(t, 20*time.Second, result.RetryAfter)
+		assert.Equal(t, time.Minute, result.ResetAfter)
+
+		// one request is replenished every period / rate
+		now = now.Add(20 * time.Second)
+		result, err = limiter.Allow(ctx, "key", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, int64(0), result.Remaining)
+
+		result, err = limiter.Allow(ctx, "other", limit)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), result.Remaining)
+	})
+
+	t.Run("wrap", func(t *testing.T) {
+		mr.FlushAll()
+		rules := []RateRule{
+			{Name: "ip", Key: limiter.ClientIP, Limit: Limit{Rate: 2, Period: time.Minute}},
+			{Name: "api_key", Key: APIKeyID, Limit: Limit{Rate: 1, Period: time.Minute}},
+		}
+		endpoint := WithErrorHandler(limiter.Wrap("test", rules, func(w http.ResponseWriter, r *http.Request) error {
+			return nil
+		}), zap.NewNop())
+		serve := func(remoteAddr string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/", nil)
+			req.RemoteAddr = remoteAddr
+			rr := httptest.NewRecorder()
+			endpoint(rr, req)
+			return rr
+		}
+
+		rr := serve("10.0.0.1:1234")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", rr.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "30", rr.Header().Get("X-RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusOK, serve("10.0.0.1:1235").Code)
+		rr = serve("10.0.0.1:1236")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+		assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+
+		// requests without an API key are only limited by IP
+		assert.Equal(t, http.StatusOK, serve("10.0.0.2:1234").Code)
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7, 10.0.0.2")
+		assert.Equal(t, "10.0.0.1", limiter.ClientIP(req))
+		// the leftmost entry is sent by the client
+		assert.Equal(t, "10.0.0.2", NewRateLimiter(client, zap.NewNop(), 1).ClientIP(req))
+		assert.Equal(t, "203.0.113.7", NewRateLimiter(client, zap.NewNop(), 2).ClientIP(req))
+		assert.Equal(t, "198.51.100.1", NewRateLimiter(client, zap.NewNop(), 5).ClientIP(req))
+	})
+}